@@ -0,0 +1,252 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sms-api/mcp-server/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketMessages = []byte("messages")
+	bucketState    = []byte("state")
+	keySyncedAt    = []byte("synced_at")
+	keyStale       = []byte("stale")
+)
+
+var (
+	openMu sync.Mutex
+	opened = map[string]*Cache{}
+)
+
+// Cache keeps a local copy of the message list per consumer and service
+type Cache struct {
+	db *bolt.DB
+}
+
+// Freshness tells callers how current cached data is
+type Freshness struct {
+	Source     string `json:"source"` // "cache" or "api"
+	SyncedAt   string `json:"synced_at,omitempty"`
+	AgeSeconds int64  `json:"age_seconds"`
+	Stale      bool   `json:"stale"`
+}
+
+// Scope identifies the message list of one consumer and service
+func Scope(consumerId, serviceId string) string {
+	if serviceId == "" {
+		serviceId = "default"
+	}
+	return consumerId + "/" + serviceId
+}
+
+// Open returns the process-wide cache stored in dataDir, opening it on first use
+func Open(dataDir string) (*Cache, error) {
+	path := filepath.Join(dataDir, "messages.db")
+	openMu.Lock()
+	defer openMu.Unlock()
+	if c, ok := opened[path]; ok {
+		return c, nil
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open message cache %s: %w", path, err)
+	}
+	c := &Cache{db: db}
+	opened[path] = c
+	return c, nil
+}
+
+// scopeBucket returns the nested bucket of a scope, creating it when writable
+func scopeBucket(tx *bolt.Tx, scope string, name []byte) (*bolt.Bucket, error) {
+	if !tx.Writable() {
+		root := tx.Bucket([]byte(scope))
+		if root == nil {
+			return nil, nil
+		}
+		return root.Bucket(name), nil
+	}
+	root, err := tx.CreateBucketIfNotExists([]byte(scope))
+	if err != nil {
+		return nil, err
+	}
+	return root.CreateBucketIfNotExists(name)
+}
+
+// Upsert stores messages, reporting how many were new and how many changed
+func (c *Cache) Upsert(scope string, messages []models.Message) (added int, updated int, err error) {
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		b, err := scopeBucket(tx, scope, bucketMessages)
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			if msg.Id == "" {
+				continue
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			existing := b.Get([]byte(msg.Id))
+			switch {
+			case existing == nil:
+				added++
+			case !bytes.Equal(existing, data):
+				updated++
+			default:
+				continue
+			}
+			if err := b.Put([]byte(msg.Id), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return added, updated, err
+}
+
+// Get returns a cached message
+func (c *Cache) Get(scope, id string) (*models.Message, bool, error) {
+	var msg *models.Message
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b, _ := scopeBucket(tx, scope, bucketMessages)
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		msg = &models.Message{}
+		return json.Unmarshal(data, msg)
+	})
+	return msg, msg != nil, err
+}
+
+// List returns every cached message of the scope, newest first
+func (c *Cache) List(scope string) ([]models.Message, error) {
+	messages := make([]models.Message, 0)
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b, _ := scopeBucket(tx, scope, bucketMessages)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, data []byte) error {
+			var msg models.Message
+			if err := json.Unmarshal(data, &msg); err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+			return nil
+		})
+	})
+	sort.SliceStable(messages, func(i, j int) bool {
+		return sortTime(messages[i]) > sortTime(messages[j])
+	})
+	return messages, err
+}
+
+// sortTime orders messages by the timestamps the list endpoint reports, as RFC 3339 strings in UTC
+func sortTime(msg models.Message) string {
+	for _, value := range []string{msg.Created_at, msg.Sent_at} {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return ""
+}
+
+// Prune removes cached messages of the scope whose IDs are not in keep, returning how many were removed
+func (c *Cache) Prune(scope string, keep map[string]bool) (int, error) {
+	removed := 0
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b, err := scopeBucket(tx, scope, bucketMessages)
+		if err != nil {
+			return err
+		}
+		stale := make([][]byte, 0)
+		if err := b.ForEach(func(id, _ []byte) error {
+			if !keep[string(id)] {
+				stale = append(stale, append([]byte(nil), id...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, id := range stale {
+			if err := b.Delete(id); err != nil {
+				return err
+			}
+		}
+		removed = len(stale)
+		return nil
+	})
+	return removed, err
+}
+
+// Invalidate drops a message (when id is set) and marks the scope stale so the next read resyncs
+func (c *Cache) Invalidate(scope, id string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if id != "" {
+			b, err := scopeBucket(tx, scope, bucketMessages)
+			if err != nil {
+				return err
+			}
+			if err := b.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		state, err := scopeBucket(tx, scope, bucketState)
+		if err != nil {
+			return err
+		}
+		return state.Put(keyStale, []byte("1"))
+	})
+}
+
+// MarkSynced records a completed sync of the scope
+func (c *Cache) MarkSynced(scope string, at time.Time) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		state, err := scopeBucket(tx, scope, bucketState)
+		if err != nil {
+			return err
+		}
+		if err := state.Delete(keyStale); err != nil {
+			return err
+		}
+		return state.Put(keySyncedAt, []byte(at.UTC().Format(time.RFC3339Nano)))
+	})
+}
+
+// Freshness reports when the scope was last synced and whether it must be synced again before
+// serving reads, either because it is older than maxAge or because a write invalidated it
+func (c *Cache) Freshness(scope string, maxAge time.Duration) (Freshness, error) {
+	f := Freshness{Source: "cache", Stale: true}
+	err := c.db.View(func(tx *bolt.Tx) error {
+		state, _ := scopeBucket(tx, scope, bucketState)
+		if state == nil {
+			return nil
+		}
+		syncedAt, err := time.Parse(time.RFC3339Nano, string(state.Get(keySyncedAt)))
+		if err != nil {
+			return nil
+		}
+		age := time.Since(syncedAt)
+		f.SyncedAt = syncedAt.Format(time.RFC3339)
+		f.AgeSeconds = int64(age.Seconds())
+		f.Stale = state.Get(keyStale) != nil || age > maxAge
+		return nil
+	})
+	return f, err
+}
@@ -2,15 +2,51 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sms-api/mcp-server/routing"
+	"github.com/sms-api/mcp-server/sendwindow"
+	"github.com/sms-api/mcp-server/templates"
 )
 
 type APIConfig struct {
-	BaseURL     string
-	BearerToken string // For OAuth2/Bearer authentication
-	APIKey      string // For API key authentication
-	BasicAuth   string // For basic authentication
-	Port        string // For server port configuration
+	BaseURL        string
+	BearerToken    string             // For OAuth2/Bearer authentication
+	APIKey         string             // For API key authentication
+	BasicAuth      string             // For basic authentication
+	Port           string             // For server port configuration
+	Transport      string             // "stdio", "http" or "https"
+	DataDir        string             // Directory for locally persisted server state
+	SendWindow     *sendwindow.Policy // Recipient local send window from SEND_WINDOW and SEND_WINDOW_MODE (nil disables)
+	TemplatesDir   string             // Directory holding message body templates
+	RateLimit      float64            // Maximum outbound API requests per second (0 disables)
+	IdempotencyTTL time.Duration      // How long idempotency keys replay the original create response
+	AppID          string             // Default Unify application ID for resources, which carry no app ID of their own
+	MessageCache   bool               // Serve message reads from a local cache synced with the list endpoint
+	CacheMaxAge    time.Duration      // How long a synced message list is served before it is synced again
+	SearchIndex    bool               // Index listed and fetched messages for full-text search
+	WebhookSecret  string             // Shared secret verifying webhook signatures; enables the webhook receiver
+	WebhookPath    string             // HTTP path of the webhook receiver
+	WebhookHeader  string             // Header carrying the webhook signature
+	EventRetention time.Duration      // How long received webhook events are kept
+	PublicURL      string             // Public base URL of the server, used to build callback URLs for sent messages
+	ScheduleQueue  string             // "off", "fallback" or "always": when future sends are held in the server's queue
+	MediaMaxBytes  int64              // Maximum total size of the media attached to one message
+	MediaRetention time.Duration      // How long media provided inline is hosted by the server
+
+	// HeaderConnection is set when a request's API_BASE_URL or credentials come from its headers and
+	// differ from the server's own. The queue runner only sends with the server's connection.
+	HeaderConnection bool
+
+	SubscriptionPollInterval time.Duration // How often subscribed message resources are polled for status changes
+
+	Failover      map[string][]string // Services tried in order for sends, per consumer ID ("*" for any other consumer)
+	RoutingPrices *routing.Table      // Price table from ROUTING_PRICES used to route sends to the cheapest service
 }
 
 func LoadAPIConfig() (*APIConfig, error) {
@@ -33,16 +69,219 @@ func LoadAPIConfig() (*APIConfig, error) {
 		return nil, fmt.Errorf("API_BASE_URL environment variable not set")
 	}
 	
+	transport = strings.ToLower(transport)
+	if transport != "http" && transport != "https" {
+		transport = "stdio"
+	}
+
 	// For HTTP/HTTPS mode (transport is "http"/"HTTP"/"https"/"HTTPS"), API_BASE_URL comes from headers
 	// so we don't require it from environment variables
 
+	// Local state (suppression lists etc.) lives under DATA_DIR, defaulting to the user cache directory
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			cacheDir = os.TempDir()
+		}
+		dataDir = filepath.Join(cacheDir, "sms-mcp-server")
+	}
+
+	rateLimit := 0.0
+	if val := os.Getenv("RATE_LIMIT"); val != "" {
+		parsed, err := strconv.ParseFloat(val, 64)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid RATE_LIMIT %q, expected requests per second", val)
+		}
+		rateLimit = parsed
+	}
+
+	idempotencyTTL := 24 * time.Hour
+	if val := os.Getenv("IDEMPOTENCY_TTL"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid IDEMPOTENCY_TTL %q, expected a duration such as 24h", val)
+		}
+		idempotencyTTL = parsed
+	}
+
+	messageCache := false
+	if val := os.Getenv("MESSAGE_CACHE"); val != "" {
+		parsed, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MESSAGE_CACHE %q, expected true or false", val)
+		}
+		messageCache = parsed
+	}
+
+	cacheMaxAge := 5 * time.Minute
+	if val := os.Getenv("CACHE_MAX_AGE"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid CACHE_MAX_AGE %q, expected a duration such as 5m", val)
+		}
+		cacheMaxAge = parsed
+	}
+
+	searchIndex := false
+	if val := os.Getenv("SEARCH_INDEX"); val != "" {
+		parsed, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SEARCH_INDEX %q, expected true or false", val)
+		}
+		searchIndex = parsed
+	}
+
+	webhookPath := os.Getenv("WEBHOOK_PATH")
+	if webhookPath == "" {
+		webhookPath = "/webhooks/sms"
+	}
+	if !strings.HasPrefix(webhookPath, "/") || webhookPath == "/" || webhookPath == "/mcp" || strings.HasPrefix(webhookPath, "/media/") {
+		return nil, fmt.Errorf("invalid WEBHOOK_PATH %q, expected a path such as /webhooks/sms", webhookPath)
+	}
+	webhookHeader := os.Getenv("WEBHOOK_SIGNATURE_HEADER")
+	if webhookHeader == "" {
+		webhookHeader = "X-Apideck-Signature"
+	}
+
+	publicURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if publicURL != "" {
+		u, err := url.Parse(publicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid PUBLIC_BASE_URL %q, expected an absolute http(s) URL", publicURL)
+		}
+		if os.Getenv("WEBHOOK_SECRET") == "" {
+			return nil, fmt.Errorf("PUBLIC_BASE_URL requires WEBHOOK_SECRET to sign callback URLs")
+		}
+		// Callback URLs and hosted media are served by the HTTP listener, which STDIO mode does not run
+		if transport == "stdio" {
+			return nil, fmt.Errorf("PUBLIC_BASE_URL requires TRANSPORT http or https, the webhook receiver does not run in STDIO mode")
+		}
+	}
+
+	eventRetention := 7 * 24 * time.Hour
+	if val := os.Getenv("EVENT_RETENTION"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid EVENT_RETENTION %q, expected a duration such as 168h", val)
+		}
+		eventRetention = parsed
+	}
+
+	scheduleQueue := strings.ToLower(os.Getenv("SCHEDULE_QUEUE"))
+	if scheduleQueue == "" {
+		scheduleQueue = "fallback"
+	}
+	if scheduleQueue != "off" && scheduleQueue != "fallback" && scheduleQueue != "always" {
+		return nil, fmt.Errorf("invalid SCHEDULE_QUEUE %q, expected off, fallback or always", scheduleQueue)
+	}
+
+	mediaMaxBytes := int64(5 << 20)
+	if val := os.Getenv("MEDIA_MAX_BYTES"); val != "" {
+		parsed, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid MEDIA_MAX_BYTES %q, expected a positive number of bytes", val)
+		}
+		mediaMaxBytes = parsed
+	}
+
+	mediaRetention := 30 * 24 * time.Hour
+	if val := os.Getenv("MEDIA_RETENTION"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid MEDIA_RETENTION %q, expected a duration such as 720h", val)
+		}
+		mediaRetention = parsed
+	}
+
+	failover, err := parseFailover(os.Getenv("FAILOVER_SERVICES"))
+	if err != nil {
+		return nil, err
+	}
+
+	sendWindow, err := sendwindow.ParsePolicy(os.Getenv("SEND_WINDOW"), os.Getenv("SEND_WINDOW_MODE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SEND_WINDOW or SEND_WINDOW_MODE: %w", err)
+	}
+
+	// Templates are reread on every use so edits apply without a restart, but a broken directory is
+	// reported now rather than on the first send
+	templatesDir := os.Getenv("TEMPLATES_DIR")
+	if templatesDir != "" {
+		if _, err := templates.Load(templatesDir); err != nil {
+			return nil, fmt.Errorf("invalid TEMPLATES_DIR: %w", err)
+		}
+	}
+
+	var routingPrices *routing.Table
+	if path := os.Getenv("ROUTING_PRICES"); path != "" {
+		if routingPrices, err = routing.Load(path); err != nil {
+			return nil, fmt.Errorf("invalid ROUTING_PRICES: %w", err)
+		}
+	}
+
+	pollInterval := 30 * time.Second
+	if val := os.Getenv("SUBSCRIPTION_POLL_INTERVAL"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid SUBSCRIPTION_POLL_INTERVAL %q, expected a duration such as 30s", val)
+		}
+		pollInterval = parsed
+	}
+
 	return &APIConfig{
-		BaseURL:     baseURL,
-		BearerToken: os.Getenv("BEARER_TOKEN"),
-		APIKey:      os.Getenv("API_KEY"),
-		BasicAuth:   os.Getenv("BASIC_AUTH"),
-		Port:        port,
+		BaseURL:        baseURL,
+		BearerToken:    os.Getenv("BEARER_TOKEN"),
+		APIKey:         os.Getenv("API_KEY"),
+		BasicAuth:      os.Getenv("BASIC_AUTH"),
+		Port:           port,
+		Transport:      transport,
+		DataDir:        dataDir,
+		SendWindow:     sendWindow,
+		TemplatesDir:   templatesDir,
+		RateLimit:      rateLimit,
+		IdempotencyTTL: idempotencyTTL,
+		AppID:          os.Getenv("APIDECK_APP_ID"),
+		MessageCache:   messageCache,
+		CacheMaxAge:    cacheMaxAge,
+		SearchIndex:    searchIndex,
+		WebhookSecret:  os.Getenv("WEBHOOK_SECRET"),
+		WebhookPath:    webhookPath,
+		WebhookHeader:  webhookHeader,
+		EventRetention: eventRetention,
+		PublicURL:      publicURL,
+		ScheduleQueue:  scheduleQueue,
+		MediaMaxBytes:  mediaMaxBytes,
+		MediaRetention: mediaRetention,
+		Failover:       failover,
+		RoutingPrices:  routingPrices,
+
+		SubscriptionPollInterval: pollInterval,
 	}, nil
 }
 
-
+// parseFailover reads FAILOVER_SERVICES, e.g. "acme=twilio,plivo;*=twilio,vonage"
+func parseFailover(value string) (map[string][]string, error) {
+	failover := map[string][]string{}
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		consumer, list, ok := strings.Cut(entry, "=")
+		consumer = strings.TrimSpace(consumer)
+		if !ok || consumer == "" {
+			return nil, fmt.Errorf("invalid FAILOVER_SERVICES entry %q, expected consumer=service,service", entry)
+		}
+		services := make([]string, 0)
+		for _, service := range strings.Split(list, ",") {
+			if service = strings.TrimSpace(service); service != "" {
+				services = append(services, service)
+			}
+		}
+		if len(services) == 0 {
+			return nil, fmt.Errorf("invalid FAILOVER_SERVICES entry %q, no services listed", entry)
+		}
+		failover[consumer] = services
+	}
+	return failover, nil
+}
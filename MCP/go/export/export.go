@@ -0,0 +1,215 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sms-api/mcp-server/models"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Supported export formats
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+// DefaultColumns are exported when no fields are selected
+var DefaultColumns = []string{
+	"id", "created_at", "sent_at", "direction", "status", "type", "from", "to", "body",
+	"number_of_units", "price.currency", "price.total_amount", "error.code", "error.message", "reference",
+}
+
+// Columns that hold integers; every other column is exported as text
+var integerColumns = map[string]bool{"number_of_units": true, "number_of_media_files": true}
+
+// Writer writes messages as rows of the selected columns
+type Writer interface {
+	Write(msg models.Message) error
+	Close() error
+}
+
+// Extension returns the file extension for a format
+func Extension(format string) string {
+	return "." + format
+}
+
+// MIMEType returns the media type for a format
+func MIMEType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// Columns splits a comma-separated fields selection (dot notation for nested properties)
+func Columns(fields string) []string {
+	columns := make([]string, 0)
+	seen := map[string]bool{}
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" && !seen[field] {
+			seen[field] = true
+			columns = append(columns, field)
+		}
+	}
+	if len(columns) == 0 {
+		return DefaultColumns
+	}
+	return columns
+}
+
+// NewWriter returns a writer for format that writes the given columns to w
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw, columns: columns}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w), columns: columns}, nil
+	case FormatParquet:
+		group := parquet.Group{}
+		for _, column := range columns {
+			if integerColumns[column] {
+				group[parquetName(column)] = parquet.Optional(parquet.Int(64))
+			} else {
+				group[parquetName(column)] = parquet.Optional(parquet.String())
+			}
+		}
+		return &parquetWriter{w: parquet.NewWriter(w, parquet.NewSchema("message", group)), columns: columns}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected %s, %s or %s", format, FormatCSV, FormatNDJSON, FormatParquet)
+}
+
+// parquetName replaces the dots of nested fields, which Parquet tools read as path separators
+func parquetName(column string) string {
+	return strings.ReplaceAll(column, ".", "_")
+}
+
+// values resolves the selected columns of a message; missing values are nil
+func values(msg models.Message, columns []string) ([]any, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	row := make([]any, len(columns))
+	for i, column := range columns {
+		var value any = doc
+		for _, key := range strings.Split(column, ".") {
+			obj, ok := value.(map[string]any)
+			if !ok {
+				value = nil
+				break
+			}
+			value = obj[key]
+		}
+		row[i] = value
+	}
+	return row, nil
+}
+
+// text renders a value as a string cell, encoding objects and lists as JSON
+func text(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func (c *csvWriter) Write(msg models.Message) error {
+	row, err := values(msg, c.columns)
+	if err != nil {
+		return err
+	}
+	record := make([]string, len(row))
+	for i, value := range row {
+		record[i] = text(value)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func (n *ndjsonWriter) Write(msg models.Message) error {
+	row, err := values(msg, n.columns)
+	if err != nil {
+		return err
+	}
+	record := make(map[string]any, len(row))
+	for i, value := range row {
+		if value != nil {
+			record[n.columns[i]] = value
+		}
+	}
+	return n.enc.Encode(record)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+type parquetWriter struct {
+	w       *parquet.Writer
+	columns []string
+}
+
+func (p *parquetWriter) Write(msg models.Message) error {
+	row, err := values(msg, p.columns)
+	if err != nil {
+		return err
+	}
+	record := make(map[string]any, len(row))
+	for i, value := range row {
+		column := p.columns[i]
+		switch {
+		case value == nil:
+			record[parquetName(column)] = nil
+		case integerColumns[column]:
+			n, _ := value.(float64)
+			record[parquetName(column)] = int64(n)
+		default:
+			record[parquetName(column)] = text(value)
+		}
+	}
+	return p.w.Write(record)
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sms-api/mcp-server/config"
+	tools_messages "github.com/sms-api/mcp-server/tools/messages"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// runExport implements the "export" subcommand by calling the export_sms_messages tool
+func runExport(cfg *config.APIConfig, argv []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	consumerId := flags.String("consumer-id", "", "ID of the consumer (required)")
+	appId := flags.String("app-id", cfg.AppID, "Unify application ID (defaults to APIDECK_APP_ID)")
+	serviceId := flags.String("service-id", "", "Service ID, when the consumer has several integrations")
+	format := flags.String("format", "csv", "Output format: csv, ndjson or parquet")
+	fields := flags.String("fields", "", "Comma-separated columns, with dot notation for nested properties")
+	output := flags.String("output", "", "File to write, or - for standard output (defaults to a file in DATA_DIR/exports)")
+	since := flags.String("since", "", "Only export messages sent (or created) at or after this date")
+	until := flags.String("until", "", "Only export messages sent (or created) before this date")
+	direction := flags.String("direction", "", "Only export messages with these comma-separated directions")
+	status := flags.String("status", "", "Only export messages with these comma-separated statuses")
+	maxMessages := flags.Int("max-messages", 0, "Stop after roughly this many rows")
+	if err := flags.Parse(argv); err != nil {
+		return err
+	}
+	if *consumerId == "" || *appId == "" {
+		flags.Usage()
+		return fmt.Errorf("-consumer-id and -app-id (or APIDECK_APP_ID) are required")
+	}
+
+	args := map[string]any{
+		"x-apideck-consumer-id": *consumerId,
+		"x-apideck-app-id":      *appId,
+		"format":                *format,
+		"output":                "file",
+	}
+	for key, val := range map[string]string{
+		"x-apideck-service-id": *serviceId, "fields": *fields, "since": *since, "until": *until,
+		"direction": *direction, "status": *status, "path": *output,
+	} {
+		if val != "" {
+			args[key] = val
+		}
+	}
+	if *output == "-" {
+		args["output"] = "resource"
+		delete(args, "path")
+	}
+	if *maxMessages > 0 {
+		args["max_messages"] = float64(*maxMessages)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "export_sms_messages"
+	request.Params.Arguments = args
+	// The CLI runs on the user's machine, so -output may name any local file whatever TRANSPORT is
+	local := *cfg
+	local.Transport = "stdio"
+	result, err := tools_messages.MessagesexportHandler(&local)(context.Background(), request)
+	if err != nil {
+		return err
+	}
+	summary := ""
+	if tc, ok := result.Content[0].(mcp.TextContent); ok {
+		summary = tc.Text
+	}
+	if result.IsError {
+		return fmt.Errorf("%s", summary)
+	}
+	if *output != "-" {
+		fmt.Fprintln(os.Stderr, summary)
+		return nil
+	}
+	embedded, ok := result.Content[1].(mcp.EmbeddedResource)
+	if !ok {
+		return fmt.Errorf("unexpected export result")
+	}
+	switch contents := embedded.Resource.(type) {
+	case mcp.TextResourceContents:
+		_, err = os.Stdout.WriteString(contents.Text)
+	case mcp.BlobResourceContents:
+		var data []byte
+		if data, err = base64.StdEncoding.DecodeString(contents.Blob); err == nil {
+			_, err = os.Stdout.Write(data)
+		}
+	}
+	return err
+}
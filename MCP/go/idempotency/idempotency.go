@@ -0,0 +1,151 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sms-api/mcp-server/store"
+)
+
+// Record states
+const (
+	StatePending   = "pending"
+	StateCompleted = "completed"
+	StateAmbiguous = "ambiguous" // The request may have reached the API; retries are refused until it expires
+)
+
+// pendingTimeout bounds how long an in-flight request blocks retries with the same key; a request
+// pending for longer was interrupted and its outcome is unknown
+const pendingTimeout = 2 * time.Minute
+
+// Record is the stored outcome of a create request
+type Record struct {
+	Fingerprint string    `json:"fingerprint"`
+	State       string    `json:"state"`
+	Response    string    `json:"response,omitempty"`
+	Error       string    `json:"error,omitempty"` // Why the outcome of an ambiguous request is unknown
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Store remembers create responses by idempotency key until they expire. The file is shared by
+// every process using the data directory (the server and the import command), so each operation
+// rereads it under a file lock and a change is saved before the lock is released.
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+var (
+	openMu sync.Mutex
+	opened = map[string]*Store{}
+)
+
+// Open returns the idempotency store under dataDir, checking that its file can be read
+func Open(dataDir string) (*Store, error) {
+	path := filepath.Join(dataDir, "idempotency.json")
+	openMu.Lock()
+	defer openMu.Unlock()
+	if s, ok := opened[path]; ok {
+		return s, nil
+	}
+	s := &Store{path: path}
+	if err := store.LoadJSON(path, &map[string]Record{}); err != nil {
+		return nil, err
+	}
+	opened[path] = s
+	return s, nil
+}
+
+// Fingerprint hashes a request payload so a reused key with a different payload can be detected
+func Fingerprint(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Begin claims key for a new request. It returns the completed record when the request was
+// already sent, the ambiguous record when it may have been, or an error when the key is in use
+// by a different or still running request. A pending request older than pendingTimeout was
+// interrupted and becomes ambiguous.
+func (s *Store) Begin(key, fingerprint string, ttl time.Duration) (*Record, error) {
+	var found *Record
+	err := s.update(func(records map[string]Record) error {
+		now := time.Now().UTC()
+		prune(records, now)
+		rec, ok := records[key]
+		if !ok {
+			records[key] = Record{Fingerprint: fingerprint, State: StatePending, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+			return nil
+		}
+		if rec.Fingerprint != fingerprint {
+			return fmt.Errorf("idempotency key %q was already used for a different message", key)
+		}
+		if rec.State == StatePending {
+			if now.Sub(rec.CreatedAt) < pendingTimeout {
+				return fmt.Errorf("a request with idempotency key %q is still in progress, retry shortly", key)
+			}
+			rec.State, rec.Error = StateAmbiguous, "the request was interrupted before its outcome was recorded"
+			records[key] = rec
+		}
+		found = &rec
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// Complete stores the response for key so later requests replay it
+func (s *Store) Complete(key, response string) error {
+	return s.update(func(records map[string]Record) error {
+		if rec, ok := records[key]; ok {
+			rec.State = StateCompleted
+			rec.Response = response
+			records[key] = rec
+		}
+		return nil
+	})
+}
+
+// Ambiguous records that a pending request failed after it may have reached the API, so retries
+// with key are refused instead of sending again
+func (s *Store) Ambiguous(key, reason string) error {
+	return s.update(func(records map[string]Record) error {
+		if rec, ok := records[key]; ok && rec.State == StatePending {
+			rec.State, rec.Error = StateAmbiguous, reason
+			records[key] = rec
+		}
+		return nil
+	})
+}
+
+// Release forgets a pending key after a request failed without being sent, allowing it to be retried
+func (s *Store) Release(key string) error {
+	return s.update(func(records map[string]Record) error {
+		if rec, ok := records[key]; ok && rec.State == StatePending {
+			delete(records, key)
+		}
+		return nil
+	})
+}
+
+// update applies change to the current records of the store file and saves them, unless change fails
+func (s *Store) update(change func(records map[string]Record) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := map[string]Record{}
+	return store.UpdateJSON(s.path, &records, func() error { return change(records) })
+}
+
+func prune(records map[string]Record, now time.Time) {
+	for key, rec := range records {
+		if now.After(rec.ExpiresAt) {
+			delete(records, key)
+		}
+	}
+}
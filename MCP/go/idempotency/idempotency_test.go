@@ -0,0 +1,209 @@
+package idempotency
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sms-api/mcp-server/store"
+)
+
+func TestStore(t *testing.T) {
+	const key, fingerprint = "consumer/key", "fingerprint"
+	tests := []struct {
+		name      string
+		setup     func(s *Store)
+		ttl       time.Duration
+		wantState string // State of the record Begin returns, "" for a new claim
+		wantErr   string
+	}{
+		{
+			name: "new key is claimed",
+		},
+		{
+			name:    "pending key is in progress",
+			setup:   func(s *Store) { s.Begin(key, fingerprint, time.Hour) },
+			wantErr: "still in progress",
+		},
+		{
+			name:    "key reused for a different message",
+			setup:   func(s *Store) { s.Begin(key, "other", time.Hour) },
+			wantErr: "different message",
+		},
+		{
+			name: "completed key replays",
+			setup: func(s *Store) {
+				s.Begin(key, fingerprint, time.Hour)
+				s.Complete(key, "response")
+			},
+			wantState: StateCompleted,
+		},
+		{
+			name: "released key is claimed again",
+			setup: func(s *Store) {
+				s.Begin(key, fingerprint, time.Hour)
+				s.Release(key)
+			},
+		},
+		{
+			name: "ambiguous key refuses retries",
+			setup: func(s *Store) {
+				s.Begin(key, fingerprint, time.Hour)
+				s.Ambiguous(key, "timeout")
+			},
+			wantState: StateAmbiguous,
+		},
+		{
+			name: "release keeps a completed key",
+			setup: func(s *Store) {
+				s.Begin(key, fingerprint, time.Hour)
+				s.Complete(key, "response")
+				s.Release(key)
+			},
+			wantState: StateCompleted,
+		},
+		{
+			name: "release keeps an ambiguous key",
+			setup: func(s *Store) {
+				s.Begin(key, fingerprint, time.Hour)
+				s.Ambiguous(key, "timeout")
+				s.Release(key)
+			},
+			wantState: StateAmbiguous,
+		},
+		{
+			name: "late completion of an ambiguous key replays",
+			setup: func(s *Store) {
+				s.Begin(key, fingerprint, time.Hour)
+				s.Ambiguous(key, "timeout")
+				s.Complete(key, "response")
+			},
+			wantState: StateCompleted,
+		},
+		{
+			name: "interrupted pending key becomes ambiguous",
+			setup: func(s *Store) {
+				s.Begin(key, fingerprint, time.Hour)
+				s.update(func(records map[string]Record) error {
+					rec := records[key]
+					rec.CreatedAt = rec.CreatedAt.Add(-pendingTimeout - time.Second)
+					records[key] = rec
+					return nil
+				})
+			},
+			wantState: StateAmbiguous,
+		},
+		{
+			name: "expired key is claimed again",
+			setup: func(s *Store) {
+				s.Begin(key, fingerprint, time.Nanosecond)
+				s.Ambiguous(key, "timeout")
+				time.Sleep(time.Millisecond)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Open(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+			rec, err := s.Begin(key, fingerprint, time.Hour)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Begin() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Begin() error = %v", err)
+			}
+			state := ""
+			if rec != nil {
+				state = rec.State
+			}
+			if state != tt.wantState {
+				t.Fatalf("Begin() state = %q, want %q", state, tt.wantState)
+			}
+			if state == "" {
+				records := map[string]Record{}
+				store.LoadJSON(s.path, &records)
+				if records[key].State != StatePending {
+					t.Fatalf("claimed key state = %q, want %q", records[key].State, StatePending)
+				}
+			}
+		})
+	}
+}
+
+func TestStorePersists(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Begin("k", "f", time.Hour)
+	s.Ambiguous("k", "timeout")
+
+	// A new process loads the ambiguous record from disk
+	openMu.Lock()
+	opened = map[string]*Store{}
+	openMu.Unlock()
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := s.Begin("k", "f", time.Hour)
+	if err != nil || rec == nil || rec.State != StateAmbiguous || rec.Error != "timeout" {
+		t.Fatalf("Begin() = %+v, %v, want the ambiguous record", rec, err)
+	}
+}
+
+func TestStoreSharedBetweenProcesses(t *testing.T) {
+	dir := t.TempDir()
+	server, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second process, such as the import command, opens the same store
+	openMu.Lock()
+	opened = map[string]*Store{}
+	openMu.Unlock()
+	importer, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server == importer {
+		t.Fatal("Open() returned the same store, want one per process")
+	}
+
+	if _, err := server.Begin("acme/k1", "f1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := importer.Begin("import/row1", "f2", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	server.Complete("acme/k1", "sent k1")
+	if _, err := importer.Begin("acme/k1", "f1", time.Hour); err != nil {
+		t.Fatalf("Begin() of a key completed by the other process = %v", err)
+	}
+	if _, err := server.Begin("import/row1", "f2", time.Hour); err == nil || !strings.Contains(err.Error(), "still in progress") {
+		t.Fatalf("Begin() of a key pending in the other process = %v, want it in progress", err)
+	}
+	importer.Complete("import/row1", "sent row1")
+
+	for _, s := range []*Store{server, importer} {
+		for _, want := range []struct{ key, fingerprint, response string }{
+			{"acme/k1", "f1", "sent k1"},
+			{"import/row1", "f2", "sent row1"},
+		} {
+			rec, err := s.Begin(want.key, want.fingerprint, time.Hour)
+			if err != nil || rec == nil || rec.Response != want.response {
+				t.Fatalf("Begin(%q) = %+v, %v, want the response %q", want.key, rec, err, want.response)
+			}
+		}
+	}
+}
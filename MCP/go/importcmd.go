@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	tools_messages "github.com/sms-api/mcp-server/tools/messages"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// runImport implements the "import" subcommand by calling the import_sms_messages tool
+func runImport(cfg *config.APIConfig, argv []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	consumerId := flags.String("consumer-id", "", "ID of the consumer (required)")
+	appId := flags.String("app-id", cfg.AppID, "Unify application ID (defaults to APIDECK_APP_ID)")
+	serviceId := flags.String("service-id", "", "Service ID, when the consumer has several integrations")
+	input := flags.String("input", "", "CSV or NDJSON file to import (required)")
+	format := flags.String("format", "", "Input format: csv or ndjson (defaults to the file extension)")
+	from := flags.String("from", "", "Sender for rows without a from value")
+	webhookURL := flags.String("webhook-url", "", "Delivery webhook for rows without a webhook_url value")
+	onlyScheduled := flags.Bool("only-scheduled", false, "Skip rows without a scheduled_at in the future")
+	dryRun := flags.Bool("dry-run", false, "Validate every row without sending anything")
+	startRow := flags.Int("start-row", 1, "First data row to process, e.g. next_row of an interrupted import")
+	maxRows := flags.Int("max-rows", 0, "Process at most this many rows")
+	failures := flags.String("failures", "", "Write failed rows with their error to this CSV file")
+	mapping := mapFlag{}
+	flags.Var(mapping, "map", "Map a source column onto a message field, e.g. -map Recipient=to (repeatable)")
+	if err := flags.Parse(argv); err != nil {
+		return err
+	}
+	if *consumerId == "" || *appId == "" || *input == "" {
+		flags.Usage()
+		return fmt.Errorf("-consumer-id, -app-id (or APIDECK_APP_ID) and -input are required")
+	}
+
+	args := map[string]any{
+		"x-apideck-consumer-id": *consumerId,
+		"x-apideck-app-id":      *appId,
+		"path":                  *input,
+		"dry_run":               *dryRun,
+		"only_scheduled":        *onlyScheduled,
+		"start_row":             float64(*startRow),
+	}
+	for key, val := range map[string]string{
+		"x-apideck-service-id": *serviceId, "format": *format, "from": *from, "webhook_url": *webhookURL, "failures_path": *failures,
+	} {
+		if val != "" {
+			args[key] = val
+		}
+	}
+	if *maxRows > 0 {
+		args["max_rows"] = float64(*maxRows)
+	}
+	if len(mapping) > 0 {
+		columns := make(map[string]any, len(mapping))
+		for column, field := range mapping {
+			columns[column] = field
+		}
+		args["mapping"] = columns
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "import_sms_messages"
+	request.Params.Arguments = args
+	// The CLI runs on the user's machine, so -input and -failures may name any local file whatever TRANSPORT is
+	local := *cfg
+	local.Transport = "stdio"
+	result, err := tools_messages.MessagesimportHandler(&local)(context.Background(), request)
+	if err != nil {
+		return err
+	}
+	for i, content := range result.Content {
+		if tc, ok := content.(mcp.TextContent); ok {
+			if result.IsError {
+				return fmt.Errorf("%s", tc.Text)
+			}
+			if i == 0 {
+				fmt.Fprintln(os.Stdout, tc.Text)
+			} else {
+				fmt.Fprintln(os.Stderr, tc.Text)
+			}
+		}
+	}
+	// A non-zero exit status lets scripts notice rows that need fixing
+	var report tools_messages.ImportReport
+	if tc, ok := result.Content[0].(mcp.TextContent); ok && json.Unmarshal([]byte(tc.Text), &report) == nil && report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", report.Failed, report.Processed)
+	}
+	return nil
+}
+
+// mapFlag collects repeated column=field flags
+type mapFlag map[string]string
+
+func (m mapFlag) String() string {
+	return fmt.Sprint(map[string]string(m))
+}
+
+func (m mapFlag) Set(value string) error {
+	column, field, ok := strings.Cut(value, "=")
+	if !ok || column == "" || field == "" {
+		return fmt.Errorf("expected column=field, got %q", value)
+	}
+	m[column] = field
+	return nil
+}
@@ -12,6 +12,12 @@ import (
 
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/media"
+	"github.com/sms-api/mcp-server/queue"
+	resources_messages "github.com/sms-api/mcp-server/resources/messages"
+	"github.com/sms-api/mcp-server/subscriptions"
+	tools_messages "github.com/sms-api/mcp-server/tools/messages"
+	"github.com/sms-api/mcp-server/webhooks"
 )
 
 func main() {
@@ -20,6 +26,32 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// One-shot CLI subcommands run the equivalent tool and exit
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			if err := runExport(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("Export failed: %v", err)
+			}
+			return
+		case "import":
+			if err := runImport(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("Import failed: %v", err)
+			}
+			return
+		}
+	}
+
+	// Resource subscriptions outlive individual HTTP requests, so one manager serves every session
+	subs := subscriptions.NewManager(cfg.SubscriptionPollInterval)
+
+	// Queued messages are sent while the server runs, including jobs left pending by a restart
+	jobs, err := queue.Open(cfg.DataDir)
+	if err != nil {
+		log.Fatalf("Failed to load scheduling queue: %v", err)
+	}
+	go jobs.Run(context.Background(), tools_messages.QueueSender(cfg))
+
 	// Check transport environment variable (both uppercase and lowercase)
 	transport := os.Getenv("TRANSPORT")
 	if transport == "" {
@@ -47,13 +79,18 @@ func main() {
 
 		mux := http.NewServeMux()
 		mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
-			// Read headers for dynamic config
-			apiCfg := &config.APIConfig{
-				BaseURL:     r.Header.Get("API_BASE_URL"),
-				BearerToken: r.Header.Get("BEARER_TOKEN"),
-				APIKey:      r.Header.Get("API_KEY"),
-				BasicAuth:   r.Header.Get("BASIC_AUTH"),
+			// Read headers for dynamic config, keeping server-level settings from the environment
+			reqCfg := *cfg
+			reqCfg.BaseURL = r.Header.Get("API_BASE_URL")
+			reqCfg.BearerToken = r.Header.Get("BEARER_TOKEN")
+			reqCfg.APIKey = r.Header.Get("API_KEY")
+			reqCfg.BasicAuth = r.Header.Get("BASIC_AUTH")
+			if appID := r.Header.Get("APIDECK_APP_ID"); appID != "" {
+				reqCfg.AppID = appID
 			}
+			reqCfg.HeaderConnection = reqCfg.BaseURL != cfg.BaseURL || reqCfg.BearerToken != cfg.BearerToken ||
+				reqCfg.APIKey != cfg.APIKey || reqCfg.BasicAuth != cfg.BasicAuth
+			apiCfg := &reqCfg
 
 			if apiCfg.BaseURL == "" {
 				http.Error(w, "Missing API_BASE_URL header", http.StatusBadRequest)
@@ -63,16 +100,34 @@ func main() {
 			log.Printf("Incoming HTTP request - BaseURL: %s", apiCfg.BaseURL)
 
 			// Create MCP server for this request
-			mcpSrv := createMCPServer(apiCfg, transport)
+			mcpSrv := createMCPServer(apiCfg, subs, transport)
 			handler := server.NewStreamableHTTPServer(mcpSrv, server.WithHTTPContextFunc(
 				func(ctx context.Context, req *http.Request) context.Context {
 					return context.WithValue(ctx, "apiConfig", apiCfg)
 				},
 			))
 
-			handler.ServeHTTP(w, r)
+			subs.WrapHTTP(handler, resources_messages.MessageFetcher(apiCfg)).ServeHTTP(w, r)
 		})
 
+		if cfg.WebhookSecret != "" {
+			receiver, err := webhookReceiver(cfg, subs)
+			if err != nil {
+				log.Fatalf("Failed to start webhook receiver: %v", err)
+			}
+			mux.Handle(cfg.WebhookPath, receiver)
+			log.Printf("Receiving webhooks on %s", cfg.WebhookPath)
+		}
+
+		if cfg.PublicURL != "" {
+			store, err := media.Open(cfg.DataDir)
+			if err != nil {
+				log.Fatalf("Failed to open media store: %v", err)
+			}
+			mux.Handle(media.Path, &media.Server{Store: store, Retention: cfg.MediaRetention})
+			log.Printf("Hosting inline media on %s", media.Path)
+		}
+
 		mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
 			w.Write([]byte(`{"status":"ok"}`))
@@ -118,9 +173,10 @@ func main() {
 
 	// STDIO Mode - default when no transport or transport is "stdio"
 	log.Println("Running in STDIO mode")
-	mcp := createMCPServer(cfg, "STDIO")
+	mcp := createMCPServer(cfg, subs, "STDIO")
+	stdin, stdout := subs.InterceptStdio("stdio", os.Stdin, os.Stdout, resources_messages.MessageFetcher(cfg))
 	go func() {
-		if err := server.ServeStdio(mcp); err != nil {
+		if err := server.NewStdioServer(mcp).Listen(context.Background(), stdin, stdout); err != nil {
 			log.Fatalf("STDIO error: %v", err)
 		}
 	}()
@@ -128,9 +184,23 @@ func main() {
 	log.Println("Received shutdown signal. Exiting STDIO mode.")
 }
 
-func createMCPServer(cfg *config.APIConfig, mode string) *server.MCPServer {
-	mcp := server.NewMCPServer("SMS API", "10.0.0",
+func createMCPServer(cfg *config.APIConfig, subs *subscriptions.Manager, mode string) *server.MCPServer {
+	// Sessions that can receive notifications (the STDIO session, or an HTTP GET stream) are
+	// attached to the subscription manager for as long as they are registered
+	var mcp *server.MCPServer
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		subs.Attach(session.SessionID(), mcp)
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		subs.Detach(session.SessionID(), mcp)
+	})
+
+	mcp = server.NewMCPServer("SMS API", "10.0.0",
 		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(true, false),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(hooks),
 		server.WithRecovery(),
 	)
 
@@ -141,5 +211,46 @@ func createMCPServer(cfg *config.APIConfig, mode string) *server.MCPServer {
 		mcp.AddTool(tool.Definition, tool.Handler)
 	}
 
+	resourceTemplates := GetAllResourceTemplates(cfg)
+	log.Printf("Loaded %d resource templates for %s mode", len(resourceTemplates), mode)
+
+	for _, template := range resourceTemplates {
+		mcp.AddResourceTemplate(template.Definition, template.Handler)
+	}
+
+	prompts := GetAllPrompts(cfg)
+	log.Printf("Loaded %d prompts for %s mode", len(prompts), mode)
+
+	for _, prompt := range prompts {
+		mcp.AddPrompt(prompt.Definition, prompt.Handler)
+	}
+
 	return mcp
-}
\ No newline at end of file
+}
+// webhookReceiver stores verified webhook events and tells sessions subscribed to the affected
+// message, or to the consumer's message list, that the resource changed
+func webhookReceiver(cfg *config.APIConfig, subs *subscriptions.Manager) (*webhooks.Receiver, error) {
+	events, err := webhooks.Open(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	return &webhooks.Receiver{
+		Secret:    cfg.WebhookSecret,
+		Header:    cfg.WebhookHeader,
+		Events:    events,
+		Retention: cfg.EventRetention,
+		OnEvent: func(event webhooks.Event) {
+			log.Printf("Webhook event %s: %s message %s status %q", event.Id, event.Type, event.MessageId, event.Status)
+			subs.Notify(func(uri string) bool {
+				parsed, err := resources_messages.ParseMessageURI(uri)
+				if err != nil || (event.ConsumerId != "" && parsed.ConsumerId != event.ConsumerId) {
+					return false
+				}
+				if parsed.Id == "" {
+					return event.ConsumerId != ""
+				}
+				return parsed.Id == event.MessageId
+			}, event.Status)
+		},
+	}, nil
+}
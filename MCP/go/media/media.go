@@ -0,0 +1,229 @@
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Path is the HTTP path under which the server hosts media provided inline
+const Path = "/media/"
+
+// MaxItems is the number of media files carriers accept in one MMS message
+const MaxItems = 10
+
+// Content types carriers accept in MMS messages, with the extension of hosted files
+var types = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"video/mp4":       ".mp4",
+	"video/3gpp":      ".3gp",
+	"audio/mpeg":      ".mp3",
+	"audio/amr":       ".amr",
+	"text/vcard":      ".vcf",
+	"text/calendar":   ".ics",
+	"text/plain":      ".txt",
+	"application/pdf": ".pdf",
+}
+
+// Hosted file names are the content hash and the extension of the content type
+var namePattern = regexp.MustCompile(`^[0-9a-f]{64}\.[0-9a-z]+$`)
+
+// ContentType normalizes a content type, dropping parameters, and checks that carriers accept it
+func ContentType(value string) (string, error) {
+	contentType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q", value)
+	}
+	if contentType == "image/jpg" {
+		contentType = "image/jpeg"
+	}
+	if _, ok := types[contentType]; !ok {
+		return "", fmt.Errorf("content type %s is not supported in MMS messages", contentType)
+	}
+	return contentType, nil
+}
+
+// probeClient only connects to public addresses, including after redirects, so probing URLs chosen
+// by clients cannot reach the server's own network
+var probeClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: publicAddress}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// Carrier-grade NAT addresses are not public either
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddress refuses connections to loopback, private, link-local and other non-public addresses
+func publicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%s is not a public address", host)
+	}
+	return nil
+}
+
+// Probe checks that a media URL is reachable and returns its content type and size when the
+// server reports them. Servers that do not answer HEAD requests are trusted.
+func Probe(ctx context.Context, url string) (contentType string, size int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("media %s is not reachable: %w", url, err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented:
+		return "", 0, nil
+	case resp.StatusCode >= 400:
+		return "", 0, fmt.Errorf("media %s is not reachable: %s", url, resp.Status)
+	}
+	if value := resp.Header.Get("Content-Type"); value != "" {
+		if contentType, err = ContentType(value); err != nil {
+			return "", 0, fmt.Errorf("media %s: %w", url, err)
+		}
+	}
+	return contentType, max(resp.ContentLength, 0), nil
+}
+
+// Store holds media provided inline so carriers can fetch it from the server
+type Store struct {
+	mu  sync.Mutex
+	dir string
+}
+
+var (
+	openMu sync.Mutex
+	opened = map[string]*Store{}
+)
+
+// Open returns the media store under dataDir
+func Open(dataDir string) (*Store, error) {
+	dir := filepath.Join(dataDir, "media")
+	openMu.Lock()
+	defer openMu.Unlock()
+	if s, ok := opened[dir]; ok {
+		return s, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir}
+	opened[dir] = s
+	return s, nil
+}
+
+// Put stores content of an accepted content type and returns its file name. Identical content
+// is stored once; storing it again restarts its retention.
+func (s *Store) Put(data []byte, contentType string) (string, error) {
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:]) + types[contentType]
+	path := filepath.Join(s.dir, name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return name, nil
+	}
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return name, os.Rename(tmp.Name(), path)
+}
+
+// Prune removes media stored longer than retention ago
+func (s *Store) Prune(retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("Failed to read media directory: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > retention {
+			os.Remove(filepath.Join(s.dir, entry.Name()))
+		}
+	}
+}
+
+// Server serves stored media below Path
+type Server struct {
+	Store     *Store
+	Retention time.Duration
+}
+
+func (h *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, Path)
+	if !namePattern.MatchString(name) {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(filepath.Join(h.Store.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to read media", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || time.Since(info.ModTime()) > h.Retention {
+		http.NotFound(w, r)
+		return
+	}
+	for contentType, ext := range types {
+		if strings.HasSuffix(name, ext) {
+			w.Header().Set("Content-Type", contentType)
+		}
+	}
+	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
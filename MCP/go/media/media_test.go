@@ -0,0 +1,48 @@
+package media
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.0.0.5:80", false},
+		{"172.16.1.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"100.64.0.1:80", false},
+		{"0.0.0.0:80", false},
+		{"[fd00::1]:80", false},
+		{"[fe80::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+	}
+	for _, tt := range tests {
+		if err := publicAddress("tcp", tt.address, nil); (err == nil) != tt.public {
+			t.Errorf("publicAddress(%s) = %v, want public %v", tt.address, err, tt.public)
+		}
+	}
+}
+
+func TestProbeRefusesLoopback(t *testing.T) {
+	requests := 0
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer internal.Close()
+
+	_, _, err := Probe(context.Background(), internal.URL+"/image.png")
+	if err == nil || !strings.Contains(err.Error(), "not a public address") || requests != 0 {
+		t.Fatalf("Probe() error = %v after %d requests, want a refused loopback address", err, requests)
+	}
+}
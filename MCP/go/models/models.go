@@ -10,6 +10,16 @@ type Tool struct {
 	Handler    func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error)
 }
 
+type ResourceTemplate struct {
+	Definition mcp.ResourceTemplate
+	Handler    func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)
+}
+
+type Prompt struct {
+	Definition mcp.Prompt
+	Handler    func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error)
+}
+
 // NotImplementedResponse represents the NotImplementedResponse schema from the OpenAPI specification
 type NotImplementedResponse struct {
 	Message string `json:"message,omitempty"` // A human-readable message providing more details about the error.
@@ -0,0 +1,58 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func AppointmentreminderHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := request.Params.Arguments
+		if err := requireArguments(args, "consumer_id", "to", "from", "appointment_time"); err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Draft an SMS appointment reminder to %s, sent from %s, for an appointment at %s.\n", args["to"], args["from"], args["appointment_time"])
+		if args["name"] != "" {
+			fmt.Fprintf(&b, "The recipient's name is %s.\n", args["name"])
+		}
+		if args["location"] != "" {
+			fmt.Fprintf(&b, "The appointment takes place at %s.\n", args["location"])
+		}
+		b.WriteString(`
+Steps:
+1. Call list_sms_templates. If a reminder template fits, preview it with render_sms_template; otherwise write the text yourself.
+2. Keep the message friendly and short: ideally one segment (160 GSM-7 characters, or 70 if it contains emoji or other non-GSM characters). Include the date, time and how to reschedule. Do not include sensitive details.
+3. Show the draft to the user with its segment count and wait for approval before sending.
+4. Once approved, send it with send_sms_template (when using a template) or post_sms_messages. Use a reference such as "reminder-<appointment>" so a retried call never sends twice.
+5. If the tool reports that the recipient opted out or that the send was scheduled for the recipient's send window, tell the user instead of working around it.
+`)
+		b.WriteString(headerInstructions(cfg, args))
+		return userPrompt("Draft an appointment reminder", b.String()), nil
+	}
+}
+
+func CreateAppointmentreminderPrompt(cfg *config.APIConfig) models.Prompt {
+	prompt := mcp.NewPrompt("draft_appointment_reminder",
+		mcp.WithPromptDescription("Draft an appointment reminder SMS, get approval and send it"),
+		mcp.WithArgument("consumer_id", mcp.RequiredArgument(), mcp.ArgumentDescription("ID of the consumer (x-apideck-consumer-id)")),
+		mcp.WithArgument("to", mcp.RequiredArgument(), mcp.ArgumentDescription("Recipient phone number in E.164 format")),
+		mcp.WithArgument("from", mcp.RequiredArgument(), mcp.ArgumentDescription("Sending phone number")),
+		mcp.WithArgument("appointment_time", mcp.RequiredArgument(), mcp.ArgumentDescription("Date and time of the appointment")),
+		mcp.WithArgument("name", mcp.ArgumentDescription("Recipient's name")),
+		mcp.WithArgument("location", mcp.ArgumentDescription("Where the appointment takes place")),
+		mcp.WithArgument("app_id", mcp.ArgumentDescription("Unify application ID (x-apideck-app-id)")),
+		mcp.WithArgument("service_id", mcp.ArgumentDescription("SMS service to use (x-apideck-service-id)")),
+	)
+
+	return models.Prompt{
+		Definition: prompt,
+		Handler:    AppointmentreminderHandler(cfg),
+	}
+}
@@ -0,0 +1,52 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func ConversationsummaryHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := request.Params.Arguments
+		if err := requireArguments(args, "consumer_id", "number"); err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Summarize the recent SMS conversation with %s.\n", args["number"])
+		if args["since"] != "" {
+			fmt.Fprintf(&b, "Only consider messages created on or after %s.\n", args["since"])
+		}
+		b.WriteString(`
+Steps:
+1. Page through get_sms_messages with limit 200, following meta.cursors.next as the cursor, until you have the recent messages (stop after a few pages or once messages are older than the period of interest).
+2. Keep messages where from or to equals the number. Inbound messages (direction "inbound") are from the contact; the others were sent by us.
+3. Order them by sent_at, falling back to created_at.
+4. Summarize: what the contact asked for or reported, what we answered, open questions and any commitments or follow-ups. Mention if the contact replied STOP or similar (check list_sms_suppressions).
+5. Quote at most a few short messages verbatim, and do not send any message.
+`)
+		b.WriteString(headerInstructions(cfg, args))
+		return userPrompt("Summarize a recent SMS conversation", b.String()), nil
+	}
+}
+
+func CreateConversationsummaryPrompt(cfg *config.APIConfig) models.Prompt {
+	prompt := mcp.NewPrompt("summarize_conversation",
+		mcp.WithPromptDescription("Summarize the recent inbound and outbound SMS conversation with a phone number"),
+		mcp.WithArgument("consumer_id", mcp.RequiredArgument(), mcp.ArgumentDescription("ID of the consumer (x-apideck-consumer-id)")),
+		mcp.WithArgument("number", mcp.RequiredArgument(), mcp.ArgumentDescription("Contact's phone number in E.164 format")),
+		mcp.WithArgument("since", mcp.ArgumentDescription("Only include messages from this date onwards (RFC 3339)")),
+		mcp.WithArgument("app_id", mcp.ArgumentDescription("Unify application ID (x-apideck-app-id)")),
+		mcp.WithArgument("service_id", mcp.ArgumentDescription("SMS service to query (x-apideck-service-id)")),
+	)
+
+	return models.Prompt{
+		Definition: prompt,
+		Handler:    ConversationsummaryHandler(cfg),
+	}
+}
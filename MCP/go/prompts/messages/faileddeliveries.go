@@ -0,0 +1,51 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func FaileddeliveriesHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := request.Params.Arguments
+		if err := requireArguments(args, "consumer_id"); err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		b.WriteString("Investigate recent failed SMS deliveries.\n")
+		if args["since"] != "" {
+			fmt.Fprintf(&b, "Only consider messages created on or after %s.\n", args["since"])
+		}
+		b.WriteString(`
+Steps:
+1. Page through get_sms_messages with limit 200, following meta.cursors.next as the cursor, and collect outbound messages with status "failed" or "undelivered".
+2. Group them by error.code and error.message, and count how many messages, recipients and countries (by calling code) each group affects.
+3. For a few representative failures, call get_sms_messages_id to confirm the details.
+4. Check list_sms_suppressions: sending to numbers that opted out is blocked locally and never reaches the provider.
+5. Report the failure rate, the main causes with likely explanations (invalid or landline numbers, carrier filtering, unreachable handsets, account or sender ID issues) and concrete next steps. Do not resend messages without the user's approval.
+`)
+		b.WriteString(headerInstructions(cfg, args))
+		return userPrompt("Investigate failed SMS deliveries", b.String()), nil
+	}
+}
+
+func CreateFaileddeliveriesPrompt(cfg *config.APIConfig) models.Prompt {
+	prompt := mcp.NewPrompt("investigate_failed_deliveries",
+		mcp.WithPromptDescription("Find failed and undelivered messages, group them by cause and suggest fixes"),
+		mcp.WithArgument("consumer_id", mcp.RequiredArgument(), mcp.ArgumentDescription("ID of the consumer (x-apideck-consumer-id)")),
+		mcp.WithArgument("since", mcp.ArgumentDescription("Only include messages from this date onwards (RFC 3339)")),
+		mcp.WithArgument("app_id", mcp.ArgumentDescription("Unify application ID (x-apideck-app-id)")),
+		mcp.WithArgument("service_id", mcp.ArgumentDescription("SMS service to query (x-apideck-service-id)")),
+	)
+
+	return models.Prompt{
+		Definition: prompt,
+		Handler:    FaileddeliveriesHandler(cfg),
+	}
+}
@@ -0,0 +1,46 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// requireArguments reports the first missing required prompt argument
+func requireArguments(args map[string]string, names ...string) error {
+	for _, name := range names {
+		if strings.TrimSpace(args[name]) == "" {
+			return fmt.Errorf("missing required argument: %s", name)
+		}
+	}
+	return nil
+}
+
+// headerInstructions tells the model which Unify headers to pass on every tool call
+func headerInstructions(cfg *config.APIConfig, args map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pass x-apideck-consumer-id %q", args["consumer_id"])
+	appId := args["app_id"]
+	if appId == "" {
+		appId = cfg.AppID
+	}
+	if appId != "" {
+		fmt.Fprintf(&b, ", x-apideck-app-id %q", appId)
+	} else {
+		b.WriteString(", x-apideck-app-id (ask the user for it if unknown)")
+	}
+	if args["service_id"] != "" {
+		fmt.Fprintf(&b, " and x-apideck-service-id %q", args["service_id"])
+	}
+	b.WriteString(" on every tool call.")
+	return b.String()
+}
+
+// userPrompt wraps instructions as a single user message
+func userPrompt(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
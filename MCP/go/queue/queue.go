@@ -0,0 +1,305 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/store"
+)
+
+// Job states
+const (
+	StatePending   = "pending"
+	StateSending   = "sending"
+	StateSent      = "sent"
+	StateFailed    = "failed"
+	StateCanceled  = "canceled"
+	StateAmbiguous = "ambiguous" // The send may have reached the API; it is not attempted again
+)
+
+const (
+	maxAttempts   = 5
+	retryBackoff  = time.Minute
+	keepFinished  = 7 * 24 * time.Hour // Sent, failed, canceled and ambiguous jobs are listed for this long
+	maxRunnerWait = time.Minute        // Other processes may add jobs, so the runner rereads the queue this often
+)
+
+// Job is a message held by the server until its send time. It keeps the Unify headers of the
+// request (consumer, app and service ID) but no API connection: the runner sends with the server's
+// own API_BASE_URL and credentials, so no secret is written to the queue file.
+type Job struct {
+	Id         string         `json:"id"`
+	ConsumerId string         `json:"consumer_id"`
+	AppId      string         `json:"app_id"`
+	ServiceId  string         `json:"service_id,omitempty"`
+	Message    models.Message `json:"message"` // Sent without scheduled_at when the job is due
+	MediaURLs  []string       `json:"media_urls,omitempty"`
+	CallId     string         `json:"call_id,omitempty"` // Call ID of the server's webhook for the message, kept until it is sent
+	SendAt     time.Time      `json:"send_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	State      string         `json:"state"`
+	Attempts   int            `json:"attempts"`
+	NextTry    time.Time      `json:"next_try,omitempty"`
+	LastError  string         `json:"last_error,omitempty"`
+	MessageId  string         `json:"message_id,omitempty"` // ID of the created message once sent
+	FinishedAt time.Time      `json:"finished_at,omitempty"`
+}
+
+// due returns when the job should next be attempted
+func (j *Job) due() time.Time {
+	if j.NextTry.After(j.SendAt) {
+		return j.NextTry
+	}
+	return j.SendAt
+}
+
+// Sender submits a due job through the create operation and returns the created message ID.
+// retry reports whether a failure proves the message was not sent, so the job can be attempted
+// again. A failure that leaves it unknown is returned as an *AmbiguousError.
+type Sender func(ctx context.Context, job Job) (messageId string, retry bool, err error)
+
+// AmbiguousError reports an attempt that may have reached the API. The job is not attempted again,
+// since that could send the message twice; it is kept for review instead.
+type AmbiguousError struct {
+	Err error
+}
+
+func (e *AmbiguousError) Error() string { return e.Err.Error() }
+
+func (e *AmbiguousError) Unwrap() error { return e.Err }
+
+// Queue persists jobs under the data directory. The queue file is shared by every process using
+// the data directory (the server and the import command), so each operation reads it under a
+// file lock and writes its change back before releasing the lock.
+type Queue struct {
+	mu   sync.Mutex
+	path string
+	wake chan struct{}
+}
+
+var (
+	openMu sync.Mutex
+	opened = map[string]*Queue{}
+)
+
+// Open returns the queue under dataDir, checking that its file can be read
+func Open(dataDir string) (*Queue, error) {
+	path := filepath.Join(dataDir, "queue.json")
+	openMu.Lock()
+	defer openMu.Unlock()
+	if q, ok := opened[path]; ok {
+		return q, nil
+	}
+	q := &Queue{path: path, wake: make(chan struct{}, 1)}
+	if err := store.LoadJSON(path, &map[string]*Job{}); err != nil {
+		return nil, err
+	}
+	opened[path] = q
+	return q, nil
+}
+
+// IdempotencyKey is sent with every attempt of a job, so an attempt interrupted after the API
+// accepted it is not sent again by a retry
+func IdempotencyKey(id string) string {
+	return "queue/" + id
+}
+
+// Add stores a new pending job and returns it
+func (q *Queue) Add(job Job) (Job, error) {
+	b := make([]byte, 8)
+	rand.Read(b)
+	job.Id = "job_" + hex.EncodeToString(b)
+	job.State = StatePending
+	job.CreatedAt = time.Now().UTC()
+	job.SendAt = job.SendAt.UTC()
+	err := q.update(func(jobs map[string]*Job) error {
+		jobs[job.Id] = &job
+		return nil
+	})
+	if err != nil {
+		return job, err
+	}
+	q.signal()
+	return job, nil
+}
+
+// List returns a consumer's jobs, optionally in one state, by send time
+func (q *Queue) List(consumerId, state string) ([]Job, error) {
+	all, err := q.load()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0)
+	for _, job := range all {
+		if job.ConsumerId == consumerId && (state == "" || job.State == state) {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].SendAt.Before(jobs[j].SendAt) })
+	return jobs, nil
+}
+
+// Cancel stops a pending job of the consumer from being sent
+func (q *Queue) Cancel(consumerId, id string) (Job, error) {
+	var canceled Job
+	err := q.update(func(jobs map[string]*Job) error {
+		job, ok := jobs[id]
+		if !ok || job.ConsumerId != consumerId {
+			return fmt.Errorf("no queued message %q", id)
+		}
+		canceled = *job
+		if job.State != StatePending {
+			return fmt.Errorf("queued message %s is %s and can no longer be canceled", id, job.State)
+		}
+		job.State = StateCanceled
+		job.FinishedAt = time.Now().UTC()
+		canceled = *job
+		return nil
+	})
+	return canceled, err
+}
+
+// Run sends due jobs until ctx is done. Jobs that were being sent when the server stopped may have
+// reached the API, so they become ambiguous instead of being sent again.
+func (q *Queue) Run(ctx context.Context, send Sender) {
+	q.recover()
+	for {
+		next := q.sendDue(ctx, send)
+		wait := maxRunnerWait
+		if !next.IsZero() {
+			wait = min(time.Until(next), maxRunnerWait)
+		}
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-q.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// recover marks the jobs left sending by a stopped server as ambiguous
+func (q *Queue) recover() {
+	err := q.update(func(jobs map[string]*Job) error {
+		for id, job := range jobs {
+			if job.State == StateSending {
+				job.State = StateAmbiguous
+				job.LastError = "the server stopped while sending, the message may have been sent; check get_sms_messages before sending it again"
+				job.FinishedAt = time.Now().UTC()
+				log.Printf("Queued message %s was being sent when the server stopped, marked ambiguous", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to save scheduling queue: %v", err)
+	}
+}
+
+// sendDue attempts every due job and returns when the next pending job is due
+func (q *Queue) sendDue(ctx context.Context, send Sender) time.Time {
+	due := make([]Job, 0)
+	err := q.update(func(jobs map[string]*Job) error {
+		now := time.Now()
+		for id, job := range jobs {
+			if job.State != StatePending && job.State != StateSending && now.Sub(job.FinishedAt) > keepFinished {
+				delete(jobs, id)
+				continue
+			}
+			if job.State == StatePending && !job.due().After(now) {
+				job.State = StateSending
+				job.Attempts++
+				due = append(due, *job)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to save scheduling queue: %v", err)
+		due = due[:0]
+	}
+
+	for _, attempt := range due {
+		messageId, retry, err := send(ctx, attempt)
+		var ambiguous *AmbiguousError
+		saveErr := q.update(func(jobs map[string]*Job) error {
+			job, ok := jobs[attempt.Id]
+			if !ok {
+				return nil
+			}
+			switch {
+			case err == nil:
+				job.State, job.MessageId, job.LastError = StateSent, messageId, ""
+				job.FinishedAt = time.Now().UTC()
+				log.Printf("Sent queued message %s as %s", job.Id, messageId)
+			case errors.As(err, &ambiguous):
+				job.State, job.LastError = StateAmbiguous, err.Error()
+				job.FinishedAt = time.Now().UTC()
+				log.Printf("Queued message %s may have been sent, not retrying: %v", job.Id, err)
+			case retry && job.Attempts < maxAttempts && ctx.Err() == nil:
+				job.State, job.LastError = StatePending, err.Error()
+				job.NextTry = time.Now().Add(retryBackoff << (job.Attempts - 1)).UTC()
+				log.Printf("Queued message %s failed (attempt %d), retrying at %s: %v", job.Id, job.Attempts, job.NextTry.Format(time.RFC3339), err)
+			case ctx.Err() != nil:
+				job.State, job.Attempts = StatePending, job.Attempts-1
+			default:
+				job.State, job.LastError = StateFailed, err.Error()
+				job.FinishedAt = time.Now().UTC()
+				log.Printf("Queued message %s failed: %v", job.Id, err)
+			}
+			return nil
+		})
+		if saveErr != nil {
+			log.Printf("Failed to save scheduling queue: %v", saveErr)
+		}
+	}
+
+	jobs, err := q.load()
+	if err != nil {
+		log.Printf("Failed to load scheduling queue: %v", err)
+		return time.Time{}
+	}
+	var next time.Time
+	for _, job := range jobs {
+		if job.State == StatePending && (next.IsZero() || job.due().Before(next)) {
+			next = job.due()
+		}
+	}
+	return next
+}
+
+// signal wakes the runner to reconsider the next due time
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// load reads the jobs from the queue file
+func (q *Queue) load() (map[string]*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := map[string]*Job{}
+	return jobs, store.ReadJSON(q.path, &jobs)
+}
+
+// update applies change to the current jobs of the queue file and saves them, unless change fails
+func (q *Queue) update(change func(jobs map[string]*Job) error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := map[string]*Job{}
+	return store.UpdateJSON(q.path, &jobs, func() error { return change(jobs) })
+}
@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newQueue(dir string) *Queue {
+	return &Queue{path: filepath.Join(dir, "queue.json"), wake: make(chan struct{}, 1)}
+}
+
+func TestSendDue(t *testing.T) {
+	tests := []struct {
+		name      string
+		retry     bool
+		err       error
+		wantState string
+	}{
+		{"sent", false, nil, StateSent},
+		{"not sent is retried", true, errors.New("Request failed: connection refused"), StatePending},
+		{"rejected fails", false, errors.New("API error: invalid number"), StateFailed},
+		{"ambiguous is not retried", true, &AmbiguousError{Err: errors.New("Ambiguous response from twilio")}, StateAmbiguous},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newQueue(t.TempDir())
+			job, err := q.Add(Job{ConsumerId: "acme", SendAt: time.Now().Add(-time.Second)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sends := 0
+			q.sendDue(context.Background(), func(ctx context.Context, job Job) (string, bool, error) {
+				sends++
+				if job.State != StateSending || job.Attempts != 1 {
+					t.Errorf("sending job in state %s, attempt %d", job.State, job.Attempts)
+				}
+				return "msg_1", tt.retry, tt.err
+			})
+			jobs, err := q.List("acme", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sends != 1 || len(jobs) != 1 || jobs[0].Id != job.Id || jobs[0].State != tt.wantState {
+				t.Fatalf("after %d sends jobs = %+v, want one %s job", sends, jobs, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestRecoverMarksSendingJobsAmbiguous(t *testing.T) {
+	q := newQueue(t.TempDir())
+	job, _ := q.Add(Job{ConsumerId: "acme", SendAt: time.Now().Add(-time.Second)})
+	q.update(func(jobs map[string]*Job) error {
+		jobs[job.Id].State = StateSending
+		return nil
+	})
+
+	q.recover()
+	q.sendDue(context.Background(), func(ctx context.Context, job Job) (string, bool, error) {
+		t.Fatalf("job %s was sent again after a restart", job.Id)
+		return "", false, nil
+	})
+	jobs, _ := q.List("acme", StateAmbiguous)
+	if len(jobs) != 1 {
+		t.Fatalf("ambiguous jobs = %+v, want the interrupted job", jobs)
+	}
+}
+
+func TestQueueSharedBetweenProcesses(t *testing.T) {
+	dir := t.TempDir()
+	server, importer := newQueue(dir), newQueue(dir)
+	first, _ := server.Add(Job{ConsumerId: "acme", SendAt: time.Now().Add(time.Hour)})
+	imported, _ := importer.Add(Job{ConsumerId: "acme", SendAt: time.Now().Add(-time.Second)})
+	if _, err := server.Cancel("acme", first.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server's runner picks up the job the import added, and its save keeps it
+	var sent []string
+	server.sendDue(context.Background(), func(ctx context.Context, job Job) (string, bool, error) {
+		sent = append(sent, job.Id)
+		return "msg_1", false, nil
+	})
+	if len(sent) != 1 || sent[0] != imported.Id {
+		t.Fatalf("sent %v, want the imported job %s", sent, imported.Id)
+	}
+	jobs, _ := importer.List("acme", "")
+	states := map[string]string{}
+	for _, job := range jobs {
+		states[job.Id] = job.State
+	}
+	if states[first.Id] != StateCanceled || states[imported.Id] != StateSent {
+		t.Fatalf("job states = %v, want %s canceled and %s sent", states, first.Id, imported.Id)
+	}
+}
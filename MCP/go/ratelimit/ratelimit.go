@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket shared by all outbound API requests
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	tokens   float64
+	last     time.Time
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   = map[float64]*Limiter{}
+)
+
+// Shared returns the process-wide limiter allowing perSecond requests per second.
+// A rate of zero or less disables limiting and returns nil.
+func Shared(perSecond float64) *Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if l, ok := shared[perSecond]; ok {
+		return l
+	}
+	burst := max(int(perSecond), 1)
+	l := &Limiter{interval: time.Duration(float64(time.Second) / perSecond), burst: burst, tokens: float64(burst), last: time.Now()}
+	shared[perSecond] = l
+	return l
+}
+
+// Wait blocks until a request may be sent or ctx is done. A nil limiter never blocks.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(float64(l.burst), l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens * float64(l.interval))
+	}
+	l.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Hand the reserved token back so cancelled callers don't slow everyone else down
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
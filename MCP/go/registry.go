@@ -3,7 +3,11 @@ package main
 import (
 	"github.com/sms-api/mcp-server/config"
 	"github.com/sms-api/mcp-server/models"
+	prompts_messages "github.com/sms-api/mcp-server/prompts/messages"
+	resources_messages "github.com/sms-api/mcp-server/resources/messages"
 	tools_messages "github.com/sms-api/mcp-server/tools/messages"
+	tools_suppression "github.com/sms-api/mcp-server/tools/suppression"
+	tools_templates "github.com/sms-api/mcp-server/tools/templates"
 )
 
 func GetAll(cfg *config.APIConfig) []models.Tool {
@@ -13,5 +17,41 @@ func GetAll(cfg *config.APIConfig) []models.Tool {
 		tools_messages.CreateMessagesdeleteTool(cfg),
 		tools_messages.CreateMessagesoneTool(cfg),
 		tools_messages.CreateMessagesupdateTool(cfg),
+		tools_messages.CreateMessagesbulkTool(cfg),
+		tools_messages.CreateMessagesconversationTool(cfg),
+		tools_messages.CreateMessagesreplyTool(cfg),
+		tools_messages.CreateMessagessyncTool(cfg),
+		tools_messages.CreateMessagessearchTool(cfg),
+		tools_messages.CreateMessagesstatsTool(cfg),
+		tools_messages.CreateMessagesexportTool(cfg),
+		tools_messages.CreateMessagesimportTool(cfg),
+		tools_messages.CreateMessageseventsTool(cfg),
+		tools_messages.CreateMessageswaitTool(cfg),
+		tools_messages.CreateMessagesscheduledTool(cfg),
+		tools_messages.CreateMessagesrescheduleTool(cfg),
+		tools_messages.CreateMessagescancelTool(cfg),
+		tools_messages.CreateMessagesqueuedTool(cfg),
+		tools_messages.CreateMessagesqueuecancelTool(cfg),
+		tools_suppression.CreateSuppressionallTool(cfg),
+		tools_suppression.CreateSuppressionaddTool(cfg),
+		tools_suppression.CreateSuppressiondeleteTool(cfg),
+		tools_templates.CreateTemplatesallTool(cfg),
+		tools_templates.CreateTemplatesrenderTool(cfg),
+		tools_templates.CreateTemplatessendTool(cfg),
+	}
+}
+
+func GetAllResourceTemplates(cfg *config.APIConfig) []models.ResourceTemplate {
+	return []models.ResourceTemplate{
+		resources_messages.CreateMessagesallResourceTemplate(cfg),
+		resources_messages.CreateMessagesoneResourceTemplate(cfg),
+	}
+}
+
+func GetAllPrompts(cfg *config.APIConfig) []models.Prompt {
+	return []models.Prompt{
+		prompts_messages.CreateAppointmentreminderPrompt(cfg),
+		prompts_messages.CreateConversationsummaryPrompt(cfg),
+		prompts_messages.CreateFaileddeliveriesPrompt(cfg),
 	}
 }
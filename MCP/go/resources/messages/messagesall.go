@@ -0,0 +1,36 @@
+package resources
+
+import (
+	"context"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	tools_messages "github.com/sms-api/mcp-server/tools/messages"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func MessagesallHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		uri, err := ParseMessageURI(request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+		args, err := uri.ToolArgs(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return readTool(ctx, request.Params.URI, "get_sms_messages", args, tools_messages.MessagesallHandler(cfg))
+	}
+}
+
+func CreateMessagesallResourceTemplate(cfg *config.APIConfig) models.ResourceTemplate {
+	template := mcp.NewResourceTemplate("sms://{consumer_id}/messages{?service_id,app_id,cursor,limit,fields}", "SMS messages",
+		mcp.WithTemplateDescription("A page of the consumer's SMS messages. Follow meta.cursors.next with ?cursor= to read the next page."),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+
+	return models.ResourceTemplate{
+		Definition: template,
+		Handler:    MessagesallHandler(cfg),
+	}
+}
@@ -0,0 +1,74 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	tools_messages "github.com/sms-api/mcp-server/tools/messages"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func MessagesoneHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		uri, err := ParseMessageURI(request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+		args, err := uri.ToolArgs(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return readTool(ctx, request.Params.URI, "get_sms_messages_id", args, tools_messages.MessagesoneHandler(cfg))
+	}
+}
+
+// MessageFetcher loads single message resources for subscription polling
+func MessageFetcher(cfg *config.APIConfig) func(ctx context.Context, uri string) (*models.Message, error) {
+	return func(ctx context.Context, uri string) (*models.Message, error) {
+		parsed, err := ParseMessageURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		args, err := parsed.ToolArgs(cfg)
+		if err != nil {
+			return nil, err
+		}
+		// Message lists are not polled; they are only updated by inbound webhook events
+		if parsed.Id == "" {
+			if cfg.WebhookSecret == "" {
+				return nil, fmt.Errorf("message list subscriptions need the webhook receiver (WEBHOOK_SECRET), subscribe to sms://{consumer_id}/messages/{id} instead")
+			}
+			return nil, nil
+		}
+		// Status changes must come from the API, not the local message cache
+		args["source"] = "api"
+		contents, err := readTool(ctx, uri, "get_sms_messages_id", args, tools_messages.MessagesoneHandler(cfg))
+		if err != nil {
+			return nil, err
+		}
+		text, ok := contents[0].(mcp.TextResourceContents)
+		if !ok {
+			return nil, fmt.Errorf("unexpected contents for %s", uri)
+		}
+		var result models.GetMessageResponse
+		if err := json.Unmarshal([]byte(text.Text), &result); err != nil {
+			return nil, fmt.Errorf("unexpected response for %s: %w", uri, err)
+		}
+		return &result.Data, nil
+	}
+}
+
+func CreateMessagesoneResourceTemplate(cfg *config.APIConfig) models.ResourceTemplate {
+	template := mcp.NewResourceTemplate("sms://{consumer_id}/messages/{id}{?service_id,app_id,fields}", "SMS message",
+		mcp.WithTemplateDescription("A single SMS message, including its current delivery status"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+
+	return models.ResourceTemplate{
+		Definition: template,
+		Handler:    MessagesoneHandler(cfg),
+	}
+}
@@ -0,0 +1,93 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MessageURI is a parsed sms://{consumer_id}/messages[/{id}] resource URI
+type MessageURI struct {
+	ConsumerId string
+	Id         string // Empty for the message list
+	Query      url.Values
+}
+
+// ParseMessageURI parses a message resource URI. Query parameters are parsed here rather than
+// taken from the template match, which only recognizes them in declaration order.
+func ParseMessageURI(uri string) (*MessageURI, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URI %q: %w", uri, err)
+	}
+	if u.Scheme != "sms" || u.Host == "" {
+		return nil, fmt.Errorf("invalid resource URI %q, expected sms://{consumer_id}/messages", uri)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if parts[0] != "messages" || len(parts) > 2 {
+		return nil, fmt.Errorf("unknown resource URI %q", uri)
+	}
+	parsed := &MessageURI{ConsumerId: u.Host, Query: u.Query()}
+	if len(parts) == 2 {
+		if parts[1] == "" {
+			return nil, fmt.Errorf("missing message id in resource URI %q", uri)
+		}
+		parsed.Id = parts[1]
+	}
+	return parsed, nil
+}
+
+// ToolArgs builds the arguments for the equivalent message tool call
+func (m *MessageURI) ToolArgs(cfg *config.APIConfig) (map[string]any, error) {
+	appId := m.Query.Get("app_id")
+	if appId == "" {
+		appId = cfg.AppID
+	}
+	if appId == "" {
+		return nil, fmt.Errorf("no Unify application ID: set APIDECK_APP_ID or add ?app_id= to the resource URI")
+	}
+	args := map[string]any{
+		"x-apideck-consumer-id": m.ConsumerId,
+		"x-apideck-app-id":      appId,
+	}
+	if serviceId := m.Query.Get("service_id"); serviceId != "" {
+		args["x-apideck-service-id"] = serviceId
+	}
+	if m.Id != "" {
+		args["id"] = m.Id
+	}
+	for _, key := range []string{"cursor", "limit", "fields"} {
+		if val := m.Query.Get(key); val != "" {
+			args[key] = val
+		}
+	}
+	return args, nil
+}
+
+// readTool runs a message tool handler and returns its JSON output as resource contents
+func readTool(ctx context.Context, uri, toolName string, args map[string]any,
+	handler func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)) ([]mcp.ResourceContents, error) {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = toolName
+	request.Params.Arguments = args
+	result, err := handler(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	text := ""
+	if len(result.Content) > 0 {
+		if tc, ok := result.Content[0].(mcp.TextContent); ok {
+			text = tc.Text
+		}
+	}
+	if result.IsError {
+		return nil, fmt.Errorf("%s", text)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: text},
+	}, nil
+}
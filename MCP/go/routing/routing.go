@@ -0,0 +1,115 @@
+package routing
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rate is the price of sending through a service to destinations starting with a prefix
+type Rate struct {
+	Service string  `yaml:"service" json:"service"`
+	Prefix  string  `yaml:"prefix" json:"prefix"` // Leading digits of the E.164 number without "+", e.g. "1" or "447"; empty matches any destination
+	Type    string  `yaml:"type" json:"type"`     // sms, mms, or empty for both
+	Price   float64 `yaml:"price" json:"price"`   // Per segment for SMS, per message for MMS
+}
+
+// Table is a price table in one currency
+type Table struct {
+	Currency string `yaml:"currency" json:"currency"`
+	Rates    []Rate `yaml:"rates" json:"rates"`
+}
+
+// Route is a service able to deliver a message, with its estimated cost
+type Route struct {
+	Service       string  `json:"service"`
+	Prefix        string  `json:"prefix"` // Prefix of the rate that applied
+	Type          string  `json:"type"`
+	UnitPrice     float64 `json:"unit_price"`
+	Units         int     `json:"units"` // Segments for SMS, 1 for MMS
+	EstimatedCost float64 `json:"estimated_cost"`
+	Currency      string  `json:"currency"`
+}
+
+// Load reads a YAML or JSON price table
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var table Table
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse price table %s: %w", path, err)
+	}
+	if len(table.Rates) == 0 {
+		return nil, fmt.Errorf("price table %s defines no rates", path)
+	}
+	for i, rate := range table.Rates {
+		if rate.Service == "" {
+			return nil, fmt.Errorf("price table %s: rate %d has no service", path, i+1)
+		}
+		if strings.Trim(rate.Prefix, "0123456789") != "" {
+			return nil, fmt.Errorf("price table %s: rate %d has prefix %q, expected digits", path, i+1, rate.Prefix)
+		}
+		if rate.Type != "" && rate.Type != "sms" && rate.Type != "mms" {
+			return nil, fmt.Errorf("price table %s: rate %d has type %q, expected sms or mms", path, i+1, rate.Type)
+		}
+		if rate.Price < 0 {
+			return nil, fmt.Errorf("price table %s: rate %d has a negative price", path, i+1)
+		}
+	}
+	return &table, nil
+}
+
+// Routes returns the services with a rate for the destination and message type, cheapest first.
+// Each service uses its most specific rate: the longest prefix, then a rate for the exact type.
+func (t *Table) Routes(to, messageType string, segments int) []Route {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, to)
+	if messageType == "" {
+		messageType = "sms"
+	}
+	units := max(segments, 1)
+	if messageType == "mms" {
+		units = 1
+	}
+
+	best := map[string]Rate{}
+	for _, rate := range t.Rates {
+		if !strings.HasPrefix(digits, rate.Prefix) || (rate.Type != "" && rate.Type != messageType) {
+			continue
+		}
+		current, ok := best[rate.Service]
+		if !ok || len(rate.Prefix) > len(current.Prefix) || (len(rate.Prefix) == len(current.Prefix) && current.Type == "" && rate.Type != "") {
+			best[rate.Service] = rate
+		}
+	}
+
+	routes := make([]Route, 0, len(best))
+	for service, rate := range best {
+		routes = append(routes, Route{
+			Service:       service,
+			Prefix:        rate.Prefix,
+			Type:          messageType,
+			UnitPrice:     rate.Price,
+			Units:         units,
+			EstimatedCost: math.Round(rate.Price*float64(units)*1e6) / 1e6,
+			Currency:      t.Currency,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].EstimatedCost != routes[j].EstimatedCost {
+			return routes[i].EstimatedCost < routes[j].EstimatedCost
+		}
+		return routes[i].Service < routes[j].Service
+	})
+	return routes
+}
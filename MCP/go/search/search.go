@@ -0,0 +1,230 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Sort orders supported by Search
+const (
+	SortRelevance = "relevance"
+	SortNewest    = "newest"
+	SortOldest    = "oldest"
+)
+
+var (
+	openMu sync.Mutex
+	opened = map[string]*Index{}
+)
+
+// Index is a full-text index over message bodies, partitioned by consumer and service
+type Index struct {
+	index bleve.Index
+}
+
+// Query describes a search within one consumer's messages
+type Query struct {
+	ConsumerId string
+	ServiceId  string
+	Text       string // Query string syntax: words, "phrases", +required, -excluded, field:value
+	From       string
+	To         string
+	Direction  string
+	Status     string
+	Type       string
+	Since      time.Time
+	Until      time.Time
+	Sort       string
+	Limit      int
+	Offset     int
+}
+
+// Hit is a matching message with its relevance score and highlighted body fragments
+type Hit struct {
+	Score     float64        `json:"score"`
+	Fragments []string       `json:"fragments,omitempty"`
+	Message   models.Message `json:"message"`
+}
+
+// Results is one page of search hits
+type Results struct {
+	Total uint64 `json:"total"`
+	Hits  []Hit  `json:"hits"`
+	Took  string `json:"took"`
+}
+
+// Open returns the process-wide index stored in dataDir, creating it on first use
+func Open(dataDir string) (*Index, error) {
+	path := filepath.Join(dataDir, "search.bleve")
+	openMu.Lock()
+	defer openMu.Unlock()
+	if idx, ok := opened[path]; ok {
+		return idx, nil
+	}
+	index, err := bleve.Open(path)
+	if err == bleve.ErrorIndexPathDoesNotExist {
+		if err := os.MkdirAll(dataDir, 0o700); err != nil {
+			return nil, err
+		}
+		index, err = bleve.New(path, newMapping())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open search index %s: %w", path, err)
+	}
+	idx := &Index{index: index}
+	opened[path] = idx
+	return idx, nil
+}
+
+func newMapping() mapping.IndexMapping {
+	keyword := func() *mapping.FieldMapping {
+		m := bleve.NewKeywordFieldMapping()
+		m.IncludeInAll = false
+		return m
+	}
+	body := bleve.NewTextFieldMapping()
+	body.Analyzer = standard.Name
+	body.Store = true
+	at := bleve.NewDateTimeFieldMapping()
+	at.IncludeInAll = false
+	source := bleve.NewTextFieldMapping()
+	source.Index = false
+	source.IncludeInAll = false
+	source.IncludeTermVectors = false
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("body", body)
+	for _, field := range []string{"consumer_id", "service_id", "from", "to", "direction", "status", "type"} {
+		doc.AddFieldMappingsAt(field, keyword())
+	}
+	doc.AddFieldMappingsAt("at", at)
+	doc.AddFieldMappingsAt("source", source)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	m.DefaultField = "body"
+	return m
+}
+
+func docId(consumerId, serviceId, id string) string {
+	return consumerId + "/" + serviceId + "/" + id
+}
+
+// Add indexes (or re-indexes) messages of a consumer and service
+func (idx *Index) Add(consumerId, serviceId string, messages []models.Message) error {
+	batch := idx.index.NewBatch()
+	for _, msg := range messages {
+		if msg.Id == "" {
+			continue
+		}
+		source, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		doc := map[string]any{
+			"consumer_id": consumerId,
+			"service_id":  serviceId,
+			"body":        msg.Body,
+			"from":        suppression.NormalizeNumber(msg.From),
+			"to":          suppression.NormalizeNumber(msg.To),
+			"direction":   strings.ToLower(msg.Direction),
+			"status":      strings.ToLower(msg.Status),
+			"type":        strings.ToLower(msg.TypeField),
+			"source":      string(source),
+		}
+		for _, value := range []string{msg.Sent_at, msg.Created_at} {
+			if t, err := time.Parse(time.RFC3339, value); err == nil {
+				doc["at"] = t
+				break
+			}
+		}
+		if err := batch.Index(docId(consumerId, serviceId, msg.Id), doc); err != nil {
+			return err
+		}
+	}
+	return idx.index.Batch(batch)
+}
+
+// Remove drops a message from the index
+func (idx *Index) Remove(consumerId, serviceId, id string) error {
+	return idx.index.Delete(docId(consumerId, serviceId, id))
+}
+
+func termQuery(field, value string) query.Query {
+	q := bleve.NewTermQuery(value)
+	q.SetField(field)
+	return q
+}
+
+// Search runs q against the consumer's indexed messages
+func (idx *Index) Search(q Query) (*Results, error) {
+	if q.ConsumerId == "" {
+		return nil, fmt.Errorf("a consumer ID is required")
+	}
+	conjuncts := []query.Query{termQuery("consumer_id", q.ConsumerId)}
+	if strings.TrimSpace(q.Text) != "" {
+		conjuncts = append(conjuncts, bleve.NewQueryStringQuery(q.Text))
+	}
+	if q.ServiceId != "" {
+		conjuncts = append(conjuncts, termQuery("service_id", q.ServiceId))
+	}
+	for field, value := range map[string]string{
+		"from":      suppression.NormalizeNumber(q.From),
+		"to":        suppression.NormalizeNumber(q.To),
+		"direction": strings.ToLower(q.Direction),
+		"status":    strings.ToLower(q.Status),
+		"type":      strings.ToLower(q.Type),
+	} {
+		if value != "" {
+			conjuncts = append(conjuncts, termQuery(field, value))
+		}
+	}
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		dates := bleve.NewDateRangeQuery(q.Since, q.Until)
+		dates.SetField("at")
+		conjuncts = append(conjuncts, dates)
+	}
+
+	request := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(conjuncts...), q.Limit, q.Offset, false)
+	request.Fields = []string{"source"}
+	request.Highlight = bleve.NewHighlightWithStyle("html")
+	request.Highlight.AddField("body")
+	switch q.Sort {
+	case "", SortRelevance:
+		request.SortBy([]string{"-_score", "-at"})
+	case SortNewest:
+		request.SortBy([]string{"-at", "-_score"})
+	case SortOldest:
+		request.SortBy([]string{"at", "-_score"})
+	default:
+		return nil, fmt.Errorf("unknown sort %q, expected %s, %s or %s", q.Sort, SortRelevance, SortNewest, SortOldest)
+	}
+
+	found, err := idx.index.Search(request)
+	if err != nil {
+		return nil, err
+	}
+	results := &Results{Total: found.Total, Hits: make([]Hit, 0, len(found.Hits)), Took: found.Took.String()}
+	for _, match := range found.Hits {
+		hit := Hit{Score: match.Score, Fragments: match.Fragments["body"]}
+		if source, ok := match.Fields["source"].(string); ok {
+			if err := json.Unmarshal([]byte(source), &hit.Message); err != nil {
+				return nil, err
+			}
+		}
+		results.Hits = append(results.Hits, hit)
+	}
+	return results, nil
+}
@@ -0,0 +1,66 @@
+package segments
+
+import "unicode/utf16"
+
+// Encodings used on the air interface
+const (
+	EncodingGSM7 = "GSM-7"
+	EncodingUCS2 = "UCS-2"
+)
+
+// GSM 03.38 basic character set
+var gsmBasic = map[rune]bool{}
+
+// GSM 03.38 extension table; each character costs an escape plus the character itself
+var gsmExtended = map[rune]bool{
+	'^': true, '{': true, '}': true, '\\': true, '[': true, '~': true, ']': true, '|': true, '€': true, '\f': true,
+}
+
+func init() {
+	basic := "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	for _, r := range basic {
+		gsmBasic[r] = true
+	}
+}
+
+// Info describes how a message body is split for delivery
+type Info struct {
+	Encoding   string `json:"encoding"`
+	Characters int    `json:"characters"`
+	Units      int    `json:"units"` // Septets for GSM-7, UTF-16 code units for UCS-2
+	Segments   int    `json:"segments"`
+	PerSegment int    `json:"per_segment"`
+}
+
+// Count returns the encoding and number of SMS segments needed to send body
+func Count(body string) Info {
+	info := Info{Encoding: EncodingGSM7}
+	septets := 0
+	for _, r := range body {
+		info.Characters++
+		switch {
+		case gsmBasic[r]:
+			septets++
+		case gsmExtended[r]:
+			septets += 2
+		default:
+			info.Encoding = EncodingUCS2
+		}
+	}
+
+	single, multi := 160, 153
+	info.Units = septets
+	if info.Encoding == EncodingUCS2 {
+		single, multi = 70, 67
+		info.Units = len(utf16.Encode([]rune(body)))
+	}
+	switch {
+	case info.Units == 0:
+		info.Segments, info.PerSegment = 0, single
+	case info.Units <= single:
+		info.Segments, info.PerSegment = 1, single
+	default:
+		info.Segments, info.PerSegment = (info.Units+multi-1)/multi, multi
+	}
+	return info
+}
@@ -0,0 +1,171 @@
+package sendwindow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Recipient zones must resolve even on hosts without a zoneinfo database
+)
+
+// Actions applied when a message falls outside the send window
+const (
+	ModeReject   = "reject"
+	ModeSchedule = "schedule"
+)
+
+// Policy allows sends only between Start and End recipient local time
+type Policy struct {
+	Start time.Duration // Offset from local midnight
+	End   time.Duration
+	Mode  string
+}
+
+// Decision describes how a send was evaluated against the policy
+type Decision struct {
+	Action      string   `json:"action"` // "allowed", "rejected" or "scheduled"
+	Timezones   []string `json:"timezones"`
+	Window      string   `json:"window"`
+	RequestedAt string   `json:"requested_at"`
+	ScheduledAt string   `json:"scheduled_at,omitempty"`
+}
+
+// ParsePolicy parses a window such as "08:00-21:00" and the out-of-window mode.
+// An empty window disables the policy and returns nil.
+func ParsePolicy(window, mode string) (*Policy, error) {
+	if strings.TrimSpace(window) == "" {
+		return nil, nil
+	}
+	startStr, endStr, ok := strings.Cut(window, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid send window %q, expected HH:MM-HH:MM", window)
+	}
+	start, err := parseClock(startStr)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return nil, err
+	}
+	if end <= start {
+		return nil, fmt.Errorf("invalid send window %q, end must be after start", window)
+	}
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case "":
+		mode = ModeSchedule
+	case ModeReject, ModeSchedule:
+	default:
+		return nil, fmt.Errorf("invalid send window mode %q, expected %q or %q", mode, ModeReject, ModeSchedule)
+	}
+	return &Policy{Start: start, End: end, Mode: mode}, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid send window time %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (p *Policy) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", int(p.Start.Hours()), int(p.Start.Minutes())%60, int(p.End.Hours()), int(p.End.Minutes())%60)
+}
+
+// RecipientZones returns the time zones for an explicit IANA override, or infers them
+// from the recipient's country calling code.
+func RecipientZones(number, override string) ([]*time.Location, error) {
+	if override != "" {
+		loc, err := time.LoadLocation(override)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", override)
+		}
+		return []*time.Location{loc}, nil
+	}
+	if !strings.HasPrefix(strings.TrimSpace(number), "+") {
+		return nil, fmt.Errorf("cannot infer time zone of %q, use E.164 format (+<country code>...) or pass recipient_timezone", number)
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	for n := min(len(digits), 4); n > 0; n-- {
+		names, ok := countryZones[digits[:n]]
+		if !ok {
+			continue
+		}
+		locs := make([]*time.Location, 0, len(names))
+		for _, name := range names {
+			loc, err := time.LoadLocation(name)
+			if err != nil {
+				return nil, err
+			}
+			locs = append(locs, loc)
+		}
+		return locs, nil
+	}
+	return nil, fmt.Errorf("no time zone known for the country code of %s, pass recipient_timezone", number)
+}
+
+func (p *Policy) allowedIn(t time.Time, loc *time.Location) bool {
+	local := t.In(loc)
+	sinceMidnight := local.Sub(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc))
+	return sinceMidnight >= p.Start && sinceMidnight < p.End
+}
+
+// nextStartIn returns the first window start in loc after t
+func (p *Policy) nextStartIn(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc).Add(p.Start)
+	if !start.After(t) {
+		start = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc).Add(p.Start)
+	}
+	return start
+}
+
+// NextAllowed returns the earliest time at or after t that is inside the window in every zone
+func (p *Policy) NextAllowed(t time.Time, locs []*time.Location) (time.Time, error) {
+	candidate := t
+	for i := 0; i < 16; i++ {
+		next := candidate
+		for _, loc := range locs {
+			if !p.allowedIn(candidate, loc) {
+				if start := p.nextStartIn(candidate, loc); start.After(next) {
+					next = start
+				}
+			}
+		}
+		if next.Equal(candidate) {
+			return candidate, nil
+		}
+		candidate = next
+	}
+	return time.Time{}, fmt.Errorf("no time satisfies the %s send window in all recipient time zones, pass recipient_timezone", p)
+}
+
+// Evaluate checks a send requested for at (now, or the caller's scheduled_at) against the policy
+func (p *Policy) Evaluate(at time.Time, locs []*time.Location) (Decision, error) {
+	names := make([]string, 0, len(locs))
+	for _, loc := range locs {
+		names = append(names, loc.String())
+	}
+	decision := Decision{Action: "allowed", Timezones: names, Window: p.String(), RequestedAt: at.UTC().Format(time.RFC3339)}
+	next, err := p.NextAllowed(at, locs)
+	if err != nil {
+		return decision, err
+	}
+	if next.Equal(at) {
+		return decision, nil
+	}
+	if p.Mode == ModeReject {
+		decision.Action = "rejected"
+		decision.ScheduledAt = next.UTC().Format(time.RFC3339)
+		return decision, fmt.Errorf("sending at %s is outside the %s recipient local send window (%s); the next allowed time is %s",
+			decision.RequestedAt, decision.Window, strings.Join(names, ", "), decision.ScheduledAt)
+	}
+	decision.Action = "scheduled"
+	decision.ScheduledAt = next.UTC().Format(time.RFC3339)
+	return decision, nil
+}
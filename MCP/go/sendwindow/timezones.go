@@ -0,0 +1,79 @@
+package sendwindow
+
+// countryZones maps E.164 calling-code prefixes to the time zones in use behind them.
+// Countries spanning several zones list all of them so the window holds everywhere;
+// more specific prefixes (e.g. NANP area codes) take precedence over shorter ones.
+var countryZones = map[string][]string{
+	// North American Numbering Plan
+	"1":    {"America/New_York", "America/Chicago", "America/Denver", "America/Los_Angeles"},
+	"1808": {"Pacific/Honolulu"},
+	"1907": {"America/Anchorage"},
+	"1787": {"America/Puerto_Rico"},
+	"1939": {"America/Puerto_Rico"},
+	"1876": {"America/Jamaica"},
+	"1868": {"America/Port_of_Spain"},
+	"1242": {"America/Nassau"},
+	"1246": {"America/Barbados"},
+
+	// Europe
+	"30":  {"Europe/Athens"},
+	"31":  {"Europe/Amsterdam"},
+	"32":  {"Europe/Brussels"},
+	"33":  {"Europe/Paris"},
+	"34":  {"Europe/Madrid"},
+	"36":  {"Europe/Budapest"},
+	"39":  {"Europe/Rome"},
+	"40":  {"Europe/Bucharest"},
+	"41":  {"Europe/Zurich"},
+	"43":  {"Europe/Vienna"},
+	"44":  {"Europe/London"},
+	"45":  {"Europe/Copenhagen"},
+	"46":  {"Europe/Stockholm"},
+	"47":  {"Europe/Oslo"},
+	"48":  {"Europe/Warsaw"},
+	"49":  {"Europe/Berlin"},
+	"351": {"Europe/Lisbon"},
+	"352": {"Europe/Luxembourg"},
+	"353": {"Europe/Dublin"},
+	"358": {"Europe/Helsinki"},
+	"380": {"Europe/Kyiv"},
+	"420": {"Europe/Prague"},
+	"421": {"Europe/Bratislava"},
+	"7":   {"Europe/Moscow", "Asia/Yekaterinburg", "Asia/Novosibirsk", "Asia/Vladivostok"},
+	"90":  {"Europe/Istanbul"},
+
+	// Latin America
+	"52": {"America/Mexico_City", "America/Tijuana", "America/Cancun"},
+	"54": {"America/Argentina/Buenos_Aires"},
+	"55": {"America/Sao_Paulo", "America/Manaus"},
+	"56": {"America/Santiago"},
+	"57": {"America/Bogota"},
+	"51": {"America/Lima"},
+
+	// Africa and Middle East
+	"20":  {"Africa/Cairo"},
+	"27":  {"Africa/Johannesburg"},
+	"234": {"Africa/Lagos"},
+	"254": {"Africa/Nairobi"},
+	"966": {"Asia/Riyadh"},
+	"971": {"Asia/Dubai"},
+	"972": {"Asia/Jerusalem"},
+
+	// Asia-Pacific
+	"60":  {"Asia/Kuala_Lumpur"},
+	"61":  {"Australia/Perth", "Australia/Adelaide", "Australia/Sydney"},
+	"62":  {"Asia/Jakarta", "Asia/Makassar", "Asia/Jayapura"},
+	"63":  {"Asia/Manila"},
+	"64":  {"Pacific/Auckland"},
+	"65":  {"Asia/Singapore"},
+	"66":  {"Asia/Bangkok"},
+	"81":  {"Asia/Tokyo"},
+	"82":  {"Asia/Seoul"},
+	"84":  {"Asia/Ho_Chi_Minh"},
+	"86":  {"Asia/Shanghai"},
+	"91":  {"Asia/Kolkata"},
+	"92":  {"Asia/Karachi"},
+	"852": {"Asia/Hong_Kong"},
+	"880": {"Asia/Dhaka"},
+	"886": {"Asia/Taipei"},
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LoadJSON decodes the JSON file at path into v. A missing file leaves v untouched.
+func LoadJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// SaveJSON writes v to path as indented JSON. The file is replaced atomically so a crash
+// mid-write never leaves a truncated state file behind.
+func SaveJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockStale is how old a lock file must be to be considered left behind by a crashed process
+const lockStale = 30 * time.Second
+
+// Lock takes an exclusive lock on the state file at path, shared by every process using the same
+// data directory, and returns the function releasing it. It waits while another process holds the
+// lock; a lock older than lockStale is taken over.
+func Lock(path string) (func(), error) {
+	lock := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(2 * lockStale)
+	for {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", lock)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// ReadJSON decodes the state file at path into v under Lock, so it never sees a change of another
+// process half applied
+func ReadJSON(path string, v any) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return LoadJSON(path, v)
+}
+
+// UpdateJSON reloads the state file at path into v, applies change and saves v back, all under
+// Lock, so changes made by other processes since the last read are kept. v must be empty, since a
+// missing file leaves it untouched. Nothing is saved when change fails.
+func UpdateJSON(path string, v any, change func() error) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := LoadJSON(path, v); err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	return SaveJSON(path, v)
+}
@@ -0,0 +1,125 @@
+package subscriptions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// JSON-RPC methods answered by the manager
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+)
+
+// HandleMessage answers resources/subscribe and resources/unsubscribe requests, which the
+// MCP server library does not route itself. It reports false for every other message.
+func (m *Manager) HandleMessage(sessionID string, message []byte, fetch Fetcher) ([]byte, bool) {
+	var request struct {
+		JSONRPC string `json:"jsonrpc"`
+		ID      any    `json:"id"`
+		Method  string `json:"method"`
+		Params  struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &request); err != nil || request.ID == nil {
+		return nil, false
+	}
+	if request.Method != methodSubscribe && request.Method != methodUnsubscribe {
+		return nil, false
+	}
+
+	var response any
+	switch {
+	case sessionID == "":
+		response = mcp.NewJSONRPCError(mcp.NewRequestId(request.ID), mcp.INVALID_REQUEST, "resource subscriptions require a session", nil)
+	case request.Params.URI == "":
+		response = mcp.NewJSONRPCError(mcp.NewRequestId(request.ID), mcp.INVALID_PARAMS, "missing resource uri", nil)
+	case request.Method == methodUnsubscribe:
+		m.Unsubscribe(sessionID, request.Params.URI)
+		response = mcp.NewJSONRPCResponse(mcp.NewRequestId(request.ID), mcp.Result{})
+	default:
+		if err := m.Subscribe(sessionID, request.Params.URI, fetch); err != nil {
+			response = mcp.NewJSONRPCError(mcp.NewRequestId(request.ID), mcp.INVALID_PARAMS, err.Error(), nil)
+		} else {
+			response = mcp.NewJSONRPCResponse(mcp.NewRequestId(request.ID), mcp.Result{})
+		}
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// lockedWriter serializes writes so intercepted responses never interleave with the server's output
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// InterceptStdio answers subscription requests read from in and forwards every other line
+// to the returned reader, which should be handed to the stdio server with the returned writer.
+func (m *Manager) InterceptStdio(sessionID string, in io.Reader, out io.Writer, fetch Fetcher) (io.Reader, io.Writer) {
+	pr, pw := io.Pipe()
+	writer := &lockedWriter{w: out}
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				if response, ok := m.HandleMessage(sessionID, bytes.TrimSpace(line), fetch); ok {
+					writer.Write(append(response, '\n'))
+				} else if _, err := pw.Write(line); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr, writer
+}
+
+// WrapHTTP answers subscription requests posted to the streamable HTTP endpoint and ends a
+// session's subscriptions when the client deletes the session.
+func (m *Manager) WrapHTTP(next http.Handler, fetch Fetcher) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.Header.Get(server.HeaderKeySessionID)
+		switch r.Method {
+		case http.MethodPost:
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			if response, ok := m.HandleMessage(sessionID, body, fetch); ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(server.HeaderKeySessionID, sessionID)
+				w.Write(response)
+				return
+			}
+		case http.MethodDelete:
+			if sessionID != "" {
+				m.UnsubscribeAll(sessionID)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
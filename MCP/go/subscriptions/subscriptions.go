@@ -0,0 +1,219 @@
+package subscriptions
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sms-api/mcp-server/models"
+)
+
+// orphanTimeout drops subscriptions of sessions that never open a notification channel
+const orphanTimeout = 5 * time.Minute
+
+// Delivery statuses after which a message no longer changes
+var terminalStatuses = map[string]bool{
+	"delivered": true, "undelivered": true, "failed": true, "canceled": true, "received": true, "read": true,
+}
+
+// Fetcher loads the current state of a subscribed message resource. It returns a nil message
+// for resources that are not polled and only change through Notify, such as message lists.
+type Fetcher func(ctx context.Context, uri string) (*models.Message, error)
+
+// Notifier delivers notifications to a connected session; *server.MCPServer implements it
+type Notifier interface {
+	SendNotificationToSpecificClient(sessionID string, method string, params map[string]any) error
+}
+
+type subscription struct {
+	uri     string
+	status  string
+	created time.Time
+	cancel  context.CancelFunc
+}
+
+// Manager tracks resource subscriptions per session and polls them for status changes
+type Manager struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	sessions  map[string]map[string]*subscription
+	notifiers map[string]Notifier
+}
+
+// NewManager returns a manager polling subscribed resources every interval
+func NewManager(interval time.Duration) *Manager {
+	return &Manager{
+		interval:  interval,
+		sessions:  map[string]map[string]*subscription{},
+		notifiers: map[string]Notifier{},
+	}
+}
+
+// Attach records the server that can reach a session, called when the session registers
+func (m *Manager) Attach(sessionID string, notifier Notifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notifiers[sessionID] = notifier
+}
+
+// Detach ends a session's subscriptions when the server that reached it unregisters the session
+func (m *Manager) Detach(sessionID string, notifier Notifier) {
+	m.mu.Lock()
+	if current, ok := m.notifiers[sessionID]; !ok || current != notifier {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.notifiers, sessionID)
+	m.mu.Unlock()
+	m.UnsubscribeAll(sessionID)
+}
+
+// Subscribe starts watching uri for the session. The resource is read once up front so
+// invalid or missing resources are rejected. Subscribing twice is a no-op.
+func (m *Manager) Subscribe(sessionID, uri string, fetch Fetcher) error {
+	m.mu.Lock()
+	_, exists := m.sessions[sessionID][uri]
+	m.mu.Unlock()
+	if exists {
+		return nil
+	}
+
+	fetchCtx, cancelFetch := context.WithTimeout(context.Background(), 30*time.Second)
+	msg, err := fetch(fetchCtx, uri)
+	cancelFetch()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	subs, ok := m.sessions[sessionID]
+	if !ok {
+		subs = map[string]*subscription{}
+		m.sessions[sessionID] = subs
+	}
+	if _, ok := subs[uri]; ok {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &subscription{uri: uri, created: time.Now(), cancel: cancel}
+	subs[uri] = sub
+	if msg == nil {
+		log.Printf("Session %s subscribed to %s", sessionID, uri)
+		return nil
+	}
+	sub.status = msg.Status
+	if !terminalStatuses[msg.Status] {
+		go m.poll(ctx, sessionID, sub, fetch)
+	}
+	log.Printf("Session %s subscribed to %s (status %q)", sessionID, uri, msg.Status)
+	return nil
+}
+
+// Notify tells every session subscribed to a resource matching uri that it changed, e.g. when a
+// webhook reports a new delivery status. A non-empty status is recorded so polling does not
+// report the same change again, and a terminal status ends polling.
+func (m *Manager) Notify(match func(uri string) bool, status string) {
+	type update struct {
+		sessionID string
+		uri       string
+		notifier  Notifier
+	}
+	updates := make([]update, 0)
+	m.mu.Lock()
+	for sessionID, subs := range m.sessions {
+		notifier, attached := m.notifiers[sessionID]
+		for uri, sub := range subs {
+			if !match(uri) {
+				continue
+			}
+			if status != "" && sub.status != "" {
+				if sub.status == status {
+					continue
+				}
+				sub.status = status
+				if terminalStatuses[status] {
+					sub.cancel()
+				}
+			}
+			if attached {
+				updates = append(updates, update{sessionID: sessionID, uri: uri, notifier: notifier})
+			}
+		}
+	}
+	m.mu.Unlock()
+	for _, u := range updates {
+		if err := u.notifier.SendNotificationToSpecificClient(u.sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": u.uri}); err != nil {
+			log.Printf("Failed to notify session %s about %s: %v", u.sessionID, u.uri, err)
+		}
+	}
+}
+
+// Unsubscribe stops watching uri for the session
+func (m *Manager) Unsubscribe(sessionID, uri string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sub, ok := m.sessions[sessionID][uri]; ok {
+		sub.cancel()
+		delete(m.sessions[sessionID], uri)
+		log.Printf("Session %s unsubscribed from %s", sessionID, uri)
+	}
+}
+
+// UnsubscribeAll stops every subscription of the session
+func (m *Manager) UnsubscribeAll(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	subs := m.sessions[sessionID]
+	for _, sub := range subs {
+		sub.cancel()
+	}
+	delete(m.sessions, sessionID)
+	if len(subs) > 0 {
+		log.Printf("Session %s ended, removed %d subscriptions", sessionID, len(subs))
+	}
+}
+
+func (m *Manager) poll(ctx context.Context, sessionID string, sub *subscription, fetch Fetcher) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		notifier, attached := m.notifiers[sessionID]
+		m.mu.Unlock()
+		if !attached {
+			if time.Since(sub.created) > orphanTimeout {
+				m.Unsubscribe(sessionID, sub.uri)
+				return
+			}
+			continue
+		}
+
+		msg, err := fetch(ctx, sub.uri)
+		if err != nil {
+			log.Printf("Failed to poll %s: %v", sub.uri, err)
+			continue
+		}
+		m.mu.Lock()
+		changed := msg.Status != sub.status
+		sub.status = msg.Status
+		m.mu.Unlock()
+		if !changed {
+			continue
+		}
+		if err := notifier.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": sub.uri}); err != nil {
+			log.Printf("Failed to notify session %s about %s: %v", sessionID, sub.uri, err)
+		}
+		if terminalStatuses[msg.Status] {
+			return
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package suppression
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/store"
+)
+
+// Sources recorded on suppression entries
+const (
+	SourceManual  = "manual"
+	SourceInbound = "inbound"
+)
+
+// Carrier opt-out and opt-in keywords (CTIA guidelines)
+var (
+	optOutKeywords = map[string]bool{"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true}
+	optInKeywords  = map[string]bool{"START": true, "YES": true, "UNSTOP": true}
+)
+
+// Entry is a phone number that must not receive messages
+type Entry struct {
+	Number    string    `json:"number"`
+	Reason    string    `json:"reason,omitempty"`
+	Source    string    `json:"source"`
+	MessageId string    `json:"message_id,omitempty"` // Inbound message that triggered the opt-out
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type consumerState struct {
+	Entries map[string]Entry `json:"entries"`
+	// LastChange holds the time of the latest opt-out/opt-in applied per number, so rescanning
+	// old inbound messages never overrides a newer manual change.
+	LastChange map[string]time.Time `json:"last_change"`
+}
+
+// List is a persistent per-consumer suppression list. The file is shared by every process using
+// the data directory (the server and the export and import commands), so each operation rereads it
+// under a file lock and a change is saved before the lock is released.
+type List struct {
+	mu   sync.Mutex
+	path string
+}
+
+var (
+	openMu sync.Mutex
+	opened = map[string]*List{}
+)
+
+// Open returns the suppression list stored under dataDir, checking that its file can be read
+func Open(dataDir string) (*List, error) {
+	path := filepath.Join(dataDir, "suppressions.json")
+	openMu.Lock()
+	defer openMu.Unlock()
+	if l, ok := opened[path]; ok {
+		return l, nil
+	}
+	l := &List{path: path}
+	if err := store.LoadJSON(path, &map[string]*consumerState{}); err != nil {
+		return nil, err
+	}
+	opened[path] = l
+	return l, nil
+}
+
+// NormalizeNumber strips formatting characters so "+1 (555) 010-0000" and "+15550100000" match
+func NormalizeNumber(number string) string {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(number) {
+		if (r >= '0' && r <= '9') || (r == '+' && i == 0) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Keyword classifies a message body as an opt-out (STOP) or opt-in (START) request
+func Keyword(body string) (optOut bool, optIn bool) {
+	word := strings.ToUpper(strings.Trim(strings.TrimSpace(body), ".!"))
+	return optOutKeywords[word], optInKeywords[word]
+}
+
+// state returns the consumer's entries in consumers, adding them when missing
+func state(consumers map[string]*consumerState, consumerId string) *consumerState {
+	cs, ok := consumers[consumerId]
+	if !ok || cs == nil {
+		cs = &consumerState{}
+		consumers[consumerId] = cs
+	}
+	if cs.Entries == nil {
+		cs.Entries = map[string]Entry{}
+	}
+	if cs.LastChange == nil {
+		cs.LastChange = map[string]time.Time{}
+	}
+	return cs
+}
+
+// load reads the consumers' entries from the list file
+func (l *List) load() (map[string]*consumerState, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	consumers := map[string]*consumerState{}
+	return consumers, store.ReadJSON(l.path, &consumers)
+}
+
+// update applies change to the consumer's current entries and saves the list file
+func (l *List) update(consumerId string, change func(cs *consumerState)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	consumers := map[string]*consumerState{}
+	return store.UpdateJSON(l.path, &consumers, func() error {
+		change(state(consumers, consumerId))
+		return nil
+	})
+}
+
+// Contains reports whether number is suppressed for the consumer
+func (l *List) Contains(consumerId, number string) (Entry, bool, error) {
+	consumers, err := l.load()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	cs, ok := consumers[consumerId]
+	if !ok || cs == nil {
+		return Entry{}, false, nil
+	}
+	entry, ok := cs.Entries[NormalizeNumber(number)]
+	return entry, ok, nil
+}
+
+// Entries returns the consumer's suppressed numbers ordered by number
+func (l *List) Entries(consumerId string) ([]Entry, error) {
+	consumers, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0)
+	if cs, ok := consumers[consumerId]; ok && cs != nil {
+		for _, entry := range cs.Entries {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Number < entries[j].Number })
+	return entries, nil
+}
+
+// Add suppresses number for the consumer
+func (l *List) Add(consumerId, number, reason string) (Entry, error) {
+	now := time.Now().UTC()
+	entry := Entry{Number: NormalizeNumber(number), Reason: reason, Source: SourceManual, AddedAt: now}
+	return entry, l.update(consumerId, func(cs *consumerState) {
+		cs.Entries[entry.Number] = entry
+		cs.LastChange[entry.Number] = now
+	})
+}
+
+// Remove lifts the suppression of number for the consumer, reporting whether it was listed
+func (l *List) Remove(consumerId, number string) (bool, error) {
+	number = NormalizeNumber(number)
+	listed := false
+	err := l.update(consumerId, func(cs *consumerState) {
+		_, listed = cs.Entries[number]
+		delete(cs.Entries, number)
+		cs.LastChange[number] = time.Now().UTC()
+	})
+	return listed, err
+}
+
+// ApplyInbound scans inbound messages for STOP/START keywords and updates the consumer's list.
+// Messages are applied oldest first so the latest keyword from a number wins.
+func (l *List) ApplyInbound(consumerId string, messages []models.Message) (added int, removed int, err error) {
+	type keywordEvent struct {
+		msg    models.Message
+		at     time.Time
+		optOut bool
+	}
+	events := make([]keywordEvent, 0)
+	for _, msg := range messages {
+		if msg.Direction != "inbound" || msg.From == "" {
+			continue
+		}
+		optOut, optIn := Keyword(msg.Body)
+		if !optOut && !optIn {
+			continue
+		}
+		at := messageTime(msg)
+		if at.IsZero() {
+			continue
+		}
+		events = append(events, keywordEvent{msg: msg, at: at, optOut: optOut})
+	}
+	if len(events) == 0 {
+		return 0, 0, nil
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+
+	err = l.update(consumerId, func(cs *consumerState) {
+		for _, ev := range events {
+			number := NormalizeNumber(ev.msg.From)
+			if !ev.at.After(cs.LastChange[number]) {
+				continue
+			}
+			cs.LastChange[number] = ev.at
+			_, listed := cs.Entries[number]
+			switch {
+			case ev.optOut && !listed:
+				cs.Entries[number] = Entry{
+					Number:    number,
+					Reason:    "Replied " + strings.ToUpper(strings.TrimSpace(ev.msg.Body)),
+					Source:    SourceInbound,
+					MessageId: ev.msg.Id,
+					AddedAt:   ev.at,
+				}
+				added++
+			case !ev.optOut && listed:
+				delete(cs.Entries, number)
+				removed++
+			}
+		}
+	})
+	return added, removed, err
+}
+
+// messageTime picks the most specific timestamp available on an inbound message
+func messageTime(msg models.Message) time.Time {
+	for _, value := range []string{msg.Sent_at, msg.Created_at, msg.Updated_at} {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,50 @@
+package suppression
+
+import (
+	"testing"
+
+	"github.com/sms-api/mcp-server/models"
+)
+
+func TestListSharedBetweenProcesses(t *testing.T) {
+	dir := t.TempDir()
+	server, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second process, such as the export command, opens the same list
+	openMu.Lock()
+	opened = map[string]*List{}
+	openMu.Unlock()
+	export, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server == export {
+		t.Fatal("Open() returned the same list, want one per process")
+	}
+
+	if _, err := server.Add("acme", "+15550100", "complaint"); err != nil {
+		t.Fatal(err)
+	}
+	stop := models.Message{Id: "msg_1", Direction: "inbound", From: "+15550101", Body: "STOP", Created_at: "2026-01-02T15:04:05Z"}
+	if added, _, err := export.ApplyInbound("acme", []models.Message{stop}); err != nil || added != 1 {
+		t.Fatalf("ApplyInbound() = %d, %v, want 1 added", added, err)
+	}
+	if _, err := server.Add("acme", "+15550102", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, list := range []*List{server, export} {
+		entries, err := list.Entries("acme")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 3 {
+			t.Fatalf("Entries() = %+v, want the opt-outs of both processes", entries)
+		}
+		if _, ok, _ := list.Contains("acme", "+1 555 010 1"); !ok {
+			t.Fatal("Contains() = false for the STOP reply, want true")
+		}
+	}
+}
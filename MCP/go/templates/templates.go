@@ -0,0 +1,327 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Variable types supported in template definitions
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+)
+
+// Variable declares a typed template input
+type Variable struct {
+	Type        string `yaml:"type" json:"type"`
+	Required    bool   `yaml:"required" json:"required,omitempty"`
+	Default     any    `yaml:"default" json:"default,omitempty"`
+	Format      string `yaml:"format" json:"format,omitempty"` // Go time layout for date variables
+	Description string `yaml:"description" json:"description,omitempty"`
+}
+
+// Template is a named message body with per-locale variants
+type Template struct {
+	Name             string              `yaml:"name" json:"name"`
+	Description      string              `yaml:"description" json:"description,omitempty"`
+	DefaultLocale    string              `yaml:"default_locale" json:"default_locale"`
+	Variables        map[string]Variable `yaml:"variables" json:"variables,omitempty"`
+	Locales          map[string]string   `yaml:"locales" json:"-"`
+	AvailableLocales []string            `yaml:"-" json:"locales"`
+}
+
+// Load reads every .yaml, .yml and .json template definition in dir. Templates are named
+// by their name field, falling back to the file name without extension.
+func Load(dir string) (map[string]*Template, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no template directory configured, set TEMPLATES_DIR")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	loaded := map[string]*Template{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var tmpl Template
+		if err := yaml.Unmarshal(data, &tmpl); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", entry.Name(), err)
+		}
+		if tmpl.Name == "" {
+			tmpl.Name = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+		if len(tmpl.Locales) == 0 {
+			return nil, fmt.Errorf("template %s defines no locales", tmpl.Name)
+		}
+		if tmpl.DefaultLocale == "" {
+			tmpl.DefaultLocale = "en"
+		}
+		if _, ok := tmpl.Locales[tmpl.DefaultLocale]; !ok {
+			return nil, fmt.Errorf("template %s has no variant for its default locale %q", tmpl.Name, tmpl.DefaultLocale)
+		}
+		for name, v := range tmpl.Variables {
+			switch v.Type {
+			case "":
+				v.Type = TypeString
+				tmpl.Variables[name] = v
+			case TypeString, TypeNumber, TypeInteger, TypeBoolean, TypeDate:
+			default:
+				return nil, fmt.Errorf("template %s: variable %s has unknown type %q", tmpl.Name, name, v.Type)
+			}
+		}
+		for locale := range tmpl.Locales {
+			tmpl.AvailableLocales = append(tmpl.AvailableLocales, locale)
+		}
+		sort.Strings(tmpl.AvailableLocales)
+		if _, dup := loaded[tmpl.Name]; dup {
+			return nil, fmt.Errorf("template %s is defined more than once", tmpl.Name)
+		}
+		loaded[tmpl.Name] = &tmpl
+	}
+	return loaded, nil
+}
+
+// ResolveLocale picks the closest available variant: exact match, then the base language, then the default
+func (t *Template) ResolveLocale(locale string) string {
+	locale = strings.ReplaceAll(locale, "_", "-")
+	candidates := []string{locale}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		candidates = append(candidates, base)
+	}
+	for _, candidate := range candidates {
+		for available := range t.Locales {
+			if strings.EqualFold(available, candidate) {
+				return available
+			}
+		}
+	}
+	return t.DefaultLocale
+}
+
+// Render validates vars against the declared variables and renders the variant for locale.
+// It returns the rendered body and the locale actually used.
+func (t *Template) Render(locale string, vars map[string]any) (string, string, error) {
+	data, err := t.coerce(vars)
+	if err != nil {
+		return "", "", err
+	}
+	resolved := t.ResolveLocale(locale)
+	tmpl, err := template.New(t.Name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"plural": pluralFunc(resolved)}).
+		Parse(t.Locales[resolved])
+	if err != nil {
+		return "", "", fmt.Errorf("template %s (%s) is invalid: %w", t.Name, resolved, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", "", fmt.Errorf("failed to render template %s (%s): %w", t.Name, resolved, err)
+	}
+	return strings.TrimSpace(out.String()), resolved, nil
+}
+
+// coerce checks each supplied value against its declared type and applies defaults
+func (t *Template) coerce(vars map[string]any) (map[string]any, error) {
+	for name := range vars {
+		if _, ok := t.Variables[name]; !ok {
+			return nil, fmt.Errorf("template %s has no variable %q", t.Name, name)
+		}
+	}
+	data := map[string]any{}
+	for name, decl := range t.Variables {
+		value, ok := vars[name]
+		if !ok || value == nil {
+			if decl.Required {
+				return nil, fmt.Errorf("missing required variable %q (%s)", name, decl.Type)
+			}
+			value = decl.Default
+		}
+		if value == nil {
+			data[name] = zeroValue(decl.Type)
+			continue
+		}
+		coerced, err := coerceValue(decl, value)
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %w", name, err)
+		}
+		data[name] = coerced
+	}
+	return data, nil
+}
+
+func zeroValue(typ string) any {
+	switch typ {
+	case TypeNumber:
+		return float64(0)
+	case TypeInteger:
+		return int64(0)
+	case TypeBoolean:
+		return false
+	default:
+		return ""
+	}
+}
+
+func coerceValue(decl Variable, value any) (any, error) {
+	switch decl.Type {
+	case TypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("expected a string, got %v", value)
+	case TypeNumber, TypeInteger:
+		var n float64
+		switch v := value.(type) {
+		case float64:
+			n = v
+		case int:
+			n = float64(v)
+		case string:
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected %s, got %q", article(decl.Type), v)
+			}
+			n = parsed
+		default:
+			return nil, fmt.Errorf("expected %s, got %v", article(decl.Type), value)
+		}
+		if decl.Type == TypeInteger {
+			if n != math.Trunc(n) {
+				return nil, fmt.Errorf("expected an integer, got %v", n)
+			}
+			return int64(n), nil
+		}
+		return n, nil
+	case TypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("expected a boolean, got %q", v)
+			}
+			return parsed, nil
+		}
+		return nil, fmt.Errorf("expected a boolean, got %v", value)
+	case TypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an RFC 3339 date-time string, got %v", value)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, s); err != nil {
+				return nil, fmt.Errorf("expected an RFC 3339 date-time, got %q", s)
+			}
+		}
+		if decl.Format != "" {
+			return t.Format(decl.Format), nil
+		}
+		return t.Format("Jan 2, 2006 15:04"), nil
+	}
+	return value, nil
+}
+
+func article(typ string) string {
+	if typ == TypeInteger {
+		return "an integer"
+	}
+	return "a " + typ
+}
+
+// pluralFunc returns the template "plural" function for a locale. It takes a count followed by
+// the locale's plural forms (one/other, or one/few/many for Slavic languages); "%d" in the
+// chosen form is replaced by the count.
+func pluralFunc(locale string) func(count any, forms ...string) (string, error) {
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	return func(count any, forms ...string) (string, error) {
+		if len(forms) == 0 {
+			return "", fmt.Errorf("plural needs at least one form")
+		}
+		var n int64
+		switch v := count.(type) {
+		case int64:
+			n = v
+		case float64:
+			n = int64(v)
+		case int:
+			n = int64(v)
+		default:
+			return "", fmt.Errorf("plural count must be a number, got %v", count)
+		}
+		index := pluralIndex(lang, n)
+		if index >= len(forms) {
+			index = len(forms) - 1
+		}
+		return strings.ReplaceAll(forms[index], "%d", strconv.FormatInt(n, 10)), nil
+	}
+}
+
+// pluralIndex implements the CLDR cardinal rules for integer counts in common languages
+func pluralIndex(lang string, n int64) int {
+	if n < 0 {
+		n = -n
+	}
+	switch lang {
+	case "ja", "zh", "ko", "vi", "th", "id", "ms":
+		return 1 // Only "other", clamped to the last supplied form
+	case "fr", "pt":
+		if n <= 1 {
+			return 0
+		}
+		return 1
+	case "ru", "uk", "be", "sr", "hr", "bs":
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	case "pl":
+		switch {
+		case n == 1:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	case "cs", "sk":
+		switch {
+		case n == 1:
+			return 0
+		case n >= 2 && n <= 4:
+			return 1
+		default:
+			return 2
+		}
+	default:
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+}
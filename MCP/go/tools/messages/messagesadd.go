@@ -5,12 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
 	"bytes"
+	"time"
 
 	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/idempotency"
 	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/ratelimit"
+	"github.com/sms-api/mcp-server/sendwindow"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/sms-api/mcp-server/webhooks"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -39,61 +46,325 @@ func MessagesaddHandler(cfg *config.APIConfig) func(ctx context.Context, request
 		} else {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal arguments: %v", err)), nil
 		}
-		
-		bodyBytes, err := json.Marshal(requestBody)
+
+		// Replay the original response when a create with the same idempotency key was already sent
+		idempotencyKey, _ := args["idempotency_key"].(string)
+		if idempotencyKey == "" {
+			idempotencyKey = requestBody.Reference
+		}
+		var keys *idempotency.Store
+		completed, ambiguous := false, ""
+		if idempotencyKey != "" {
+			var err error
+			if keys, err = idempotency.Open(cfg.DataDir); err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to load idempotency store", err), nil
+			}
+			// Media is part of the message, as given before it is resolved
+			fingerprint, err := json.Marshal(struct {
+				models.Message
+				Media any `json:"media,omitempty"`
+			}{requestBody, args["media"]})
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to encode request body", err), nil
+			}
+			idempotencyKey = fmt.Sprintf("%v/%s", args["x-apideck-consumer-id"], idempotencyKey)
+			rec, err := keys.Begin(idempotencyKey, idempotency.Fingerprint(fingerprint), cfg.IdempotencyTTL)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Idempotency check failed", err), nil
+			}
+			if rec != nil && rec.State == idempotency.StateAmbiguous {
+				return mcp.NewToolResultError(fmt.Sprintf(
+					"Ambiguous outcome: a request with this idempotency key at %s may have been sent (%s). Check get_sms_messages before sending again, with a new idempotency key only if it was not sent.",
+					rec.CreatedAt.Format(time.RFC3339), rec.Error)), nil
+			}
+			if rec != nil {
+				return withNotes(mcp.NewToolResultText(rec.Response), []string{fmt.Sprintf(
+					"Idempotent replay: a message with this idempotency key was already sent at %s; returning the original response without sending again.",
+					rec.CreatedAt.Format(time.RFC3339))}), nil
+			}
+		}
+		defer func() {
+			// Attempts that failed without sending free the key so the caller can retry; after an
+			// ambiguous failure the key refuses retries, which could send the message twice
+			if keys == nil || completed {
+				return
+			}
+			if ambiguous != "" {
+				if err := keys.Ambiguous(idempotencyKey, ambiguous); err != nil {
+					log.Printf("Failed to record ambiguous idempotency key: %v", err)
+				}
+			} else if err := keys.Release(idempotencyKey); err != nil {
+				log.Printf("Failed to release idempotency key: %v", err)
+			}
+		}()
+
+		// Honor opt-outs before anything reaches the carrier
+		suppressions, err := suppression.Open(cfg.DataDir)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to encode request body", err), nil
+			return mcp.NewToolResultErrorFromErr("Failed to load suppression list", err), nil
 		}
-		url := fmt.Sprintf("%s/sms/messages%s", cfg.BaseURL, queryString)
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(bodyBytes))
-		req.Header.Set("Content-Type", "application/json")
+		entry, suppressed, err := suppressions.Contains(fmt.Sprintf("%v", args["x-apideck-consumer-id"]), requestBody.To)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
+			return mcp.NewToolResultErrorFromErr("Failed to load suppression list", err), nil
 		}
-		// Set authentication based on auth type
-		// Fallback to single auth parameter
-		if cfg.APIKey != "" {
-			req.Header.Set("Authorization", cfg.APIKey)
+		if suppressed {
+			reason := entry.Reason
+			if reason == "" {
+				reason = "added " + entry.Source
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("Recipient %s has opted out of messages (%s, since %s). Remove the number with remove_sms_suppression only if they have opted back in.", entry.Number, reason, entry.AddedAt.Format(time.RFC3339))), nil
 		}
-		req.Header.Set("Accept", "application/json")
-		if val, ok := args["x-apideck-consumer-id"]; ok {
-			req.Header.Set("x-apideck-consumer-id", fmt.Sprintf("%v", val))
+
+		// Enforce the recipient local send window, deferring or rejecting out-of-window sends
+		notes := make([]string, 0)
+		if policy := cfg.SendWindow; policy != nil {
+			override, _ := args["recipient_timezone"].(string)
+			zones, err := sendwindow.RecipientZones(requestBody.To, override)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Cannot apply send window", err), nil
+			}
+			sendAt := time.Now()
+			if requestBody.Scheduled_at != "" {
+				if sendAt, err = time.Parse(time.RFC3339, requestBody.Scheduled_at); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Invalid scheduled_at %q, expected an RFC 3339 date-time", requestBody.Scheduled_at)), nil
+				}
+			}
+			decision, err := policy.Evaluate(sendAt, zones)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Send window violation", err), nil
+			}
+			if decision.Action == "scheduled" {
+				requestBody.Scheduled_at = decision.ScheduledAt
+			}
+			decisionJSON, err := json.Marshal(decision)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+			}
+			notes = append(notes, fmt.Sprintf("Send window: %s", decisionJSON))
 		}
-		if val, ok := args["x-apideck-app-id"]; ok {
-			req.Header.Set("x-apideck-app-id", fmt.Sprintf("%v", val))
+
+		// Attached media makes the message an MMS. Media is resolved only for sends that pass the checks
+		// above, since URLs are probed and inline content is hosted by the server.
+		var mediaURLs []string
+		if val, ok := args["media"]; ok {
+			urls, err := resolveMedia(ctx, cfg, val)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Invalid media", err), nil
+			}
+			mediaURLs = urls
 		}
-		if val, ok := args["x-apideck-service-id"]; ok {
-			req.Header.Set("x-apideck-service-id", fmt.Sprintf("%v", val))
+		if len(mediaURLs) > 0 {
+			requestBody.TypeField = "mms"
+			requestBody.Number_of_media_files = len(mediaURLs)
 		}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
+		// Route delivery events back to this server unless the caller chose a webhook. The callback URL
+		// is signed for each service tried, so events carry the service that sent the message.
+		callId := ""
+		if requestBody.Webhook_url == "" && cfg.PublicURL != "" {
+			if callId, _ = ctx.Value(webhookCallIdKey{}).(string); callId == "" {
+				callId = webhooks.NewCallId()
+			}
+			notes = append(notes, fmt.Sprintf("Webhook: delivery events for this message are received by the server, see get_sms_events with call_id %s", callId))
 		}
-		defer resp.Body.Close()
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
+		// Future sends can be held in the server's queue and created when due
+		sendAt, future := queueSendTime(requestBody.Scheduled_at)
+		enqueue := func(reason string) *mcp.CallToolResult {
+			text, err := enqueueMessage(cfg, args, requestBody, mediaURLs, callId, sendAt, reason)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to queue message", err)
+			}
+			completeIdempotency(keys, idempotencyKey, text, &completed)
+			notes = append(notes, fmt.Sprintf("Queued: the server sends this message at %s while it is running; see list_queued_sms and cancel_queued_sms", sendAt.UTC().Format(time.RFC3339)))
+			return withNotes(mcp.NewToolResultText(text), notes)
+		}
+		if future && cfg.ScheduleQueue == "always" {
+			return enqueue("SCHEDULE_QUEUE is always"), nil
+		}
+
+		if len(mediaURLs) > 0 {
+			notes = append(notes, fmt.Sprintf("Media: %d attachments sent as media_urls, which is not part of the Unify API; connectors that do not read it deliver the message without them", len(mediaURLs)))
 		}
+		url := fmt.Sprintf("%s/sms/messages%s", cfg.BaseURL, queryString)
 
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
+		// Try the consumer's services in failover order until one accepts the message. Only failures that
+		// prove the message was not sent move on; after an ambiguous one a retry could send it twice.
+		requested, route := routeMessage(cfg, args, requestBody)
+		services := failoverServices(cfg, args, requested)
+		if route != nil {
+			services = route.backups(services)
 		}
+		failures := make([]string, 0)
+		var body []byte
+		sentBy := ""
+		for i, serviceId := range services {
+			last := i == len(services)-1
+			attempt := requestBody
+			if callId != "" {
+				if attempt.Webhook_url, err = callbackURL(cfg, args, requestBody, serviceId, callId); err != nil {
+					return mcp.NewToolResultErrorFromErr("Failed to sign webhook token", err), nil
+				}
+			}
+			bodyBytes, err := withMediaURLs(attempt, mediaURLs)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to encode request body", err), nil
+			}
+			req, err := http.NewRequest("POST", url, bytes.NewBuffer(bodyBytes))
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
+			}
+			req.Header.Set("Content-Type", "application/json")
+			// Set authentication based on auth type
+			// Fallback to single auth parameter
+			if cfg.APIKey != "" {
+				req.Header.Set("Authorization", cfg.APIKey)
+			}
+			req.Header.Set("Accept", "application/json")
+			if val, ok := args["x-apideck-consumer-id"]; ok {
+				req.Header.Set("x-apideck-consumer-id", fmt.Sprintf("%v", val))
+			}
+			if val, ok := args["x-apideck-app-id"]; ok {
+				req.Header.Set("x-apideck-app-id", fmt.Sprintf("%v", val))
+			}
+			if serviceId != "" {
+				req.Header.Set("x-apideck-service-id", serviceId)
+			}
+
+			if err := ratelimit.Shared(cfg.RateLimit).Wait(ctx); err != nil {
+				return mcp.NewToolResultErrorFromErr("Request cancelled while waiting for the rate limiter", err), nil
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				if requestNotSent(err) && !last {
+					failures = append(failures, fmt.Sprintf("%s: %v", serviceName(serviceId), err))
+					continue
+				}
+				if requestNotSent(err) {
+					return withNotes(mcp.NewToolResultErrorFromErr("Request failed", err), failoverNotes(failures)), nil
+				}
+				ambiguous = fmt.Sprintf("%s: %v", serviceName(serviceId), err)
+				return withNotes(mcp.NewToolResultErrorFromErr(ambiguousSend(serviceId, len(services)), err), failoverNotes(failures)), nil
+			}
+			body, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				ambiguous = fmt.Sprintf("%s: failed to read response body: %v", serviceName(serviceId), err)
+				return withNotes(mcp.NewToolResultErrorFromErr(ambiguousSend(serviceId, len(services)), err), failoverNotes(failures)), nil
+			}
+
+			if resp.StatusCode >= 400 {
+				if !last && failoverStatus(resp.StatusCode) {
+					failures = append(failures, fmt.Sprintf("%s: API error %d: %s", serviceName(serviceId), resp.StatusCode, body))
+					continue
+				}
+				// Connectors without native scheduling reject the send; the queue sends it when due instead
+				if future && cfg.ScheduleQueue == "fallback" && notImplemented(string(body)) {
+					notes = append(notes, failoverNotes(failures)...)
+					return enqueue("the connector does not support scheduled messages"), nil
+				}
+				if resp.StatusCode >= 500 && !failoverStatus(resp.StatusCode) {
+					ambiguous = fmt.Sprintf("%s: API error %d", serviceName(serviceId), resp.StatusCode)
+					return withNotes(mcp.NewToolResultError(fmt.Sprintf("%s: API error: %s", ambiguousSend(serviceId, len(services)), body)), failoverNotes(failures)), nil
+				}
+				return withNotes(mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), failoverNotes(failures)), nil
+			}
+			sentBy = serviceId
+			break
+		}
+		if len(failures) > 0 {
+			notes = append(notes, fmt.Sprintf("Failover: sent through %s", serviceName(sentBy)))
+			notes = append(notes, failoverNotes(failures)...)
+		}
+		if route != nil {
+			if sentBy != route.Service {
+				decision := RouteFailover
+				if sentBy == services[0] {
+					decision = route.Decision
+				}
+				route.use(sentBy, decision)
+			}
+			routeJSON, err := json.Marshal(route)
+			if err != nil {
+				completeIdempotency(keys, idempotencyKey, string(body), &completed)
+				return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+			}
+			notes = append(notes, fmt.Sprintf("Route: %s", routeJSON))
+		}
+
+		// The new message is not in the cached list yet
+		cacheArgs := args
+		if sentBy != "" {
+			cacheArgs = make(map[string]any, len(args))
+			for key, val := range args {
+				cacheArgs[key] = val
+			}
+			cacheArgs["x-apideck-service-id"] = sentBy
+		}
+		invalidateCache(cfg, cacheArgs, "")
 		// Use properly typed response
 		var result models.CreateMessageResponse
 		if err := json.Unmarshal(body, &result); err != nil {
 			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
+			completeIdempotency(keys, idempotencyKey, string(body), &completed)
+			return withNotes(mcp.NewToolResultText(string(body)), notes), nil
+		}
+		// Record the service that sent the message when the API does not name it
+		if result.Service == "" && sentBy != "" {
+			result.Service = sentBy
 		}
 
 		prettyJSON, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
+			completeIdempotency(keys, idempotencyKey, string(body), &completed)
 			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
 		}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+		completeIdempotency(keys, idempotencyKey, string(prettyJSON), &completed)
+		return withNotes(mcp.NewToolResultText(string(prettyJSON)), notes), nil
+	}
+}
+
+// webhookCallIdKey carries the call ID of a queued message to its send, so its events keep the call
+// ID reported when it was queued
+type webhookCallIdKey struct{}
+
+// callbackURL returns the server's webhook URL for a message sent through serviceId, with a token
+// identifying the call
+func callbackURL(cfg *config.APIConfig, args map[string]any, msg models.Message, serviceId, callId string) (string, error) {
+	claims := webhooks.TokenClaims{
+		ConsumerId: fmt.Sprintf("%v", args["x-apideck-consumer-id"]),
+		ServiceId:  serviceId,
+		Reference:  msg.Reference,
+		CallId:     callId,
+	}
+	if sendAt, future := queueSendTime(msg.Scheduled_at); future {
+		claims.SendAt = sendAt.Unix()
+	}
+	token, err := webhooks.NewToken(cfg.WebhookSecret, claims)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s?token=%s", cfg.PublicURL, cfg.WebhookPath, token), nil
+}
+
+// completeIdempotency stores the response of an accepted create under its idempotency key
+func completeIdempotency(keys *idempotency.Store, key, response string, completed *bool) {
+	if keys == nil {
+		return
+	}
+	*completed = true
+	if err := keys.Complete(key, response); err != nil {
+		log.Printf("Failed to store idempotent response: %v", err)
+	}
+}
+
+// withNotes appends notes about policies applied to the send after the API response
+func withNotes(result *mcp.CallToolResult, notes []string) *mcp.CallToolResult {
+	for _, note := range notes {
+		result.Content = append(result.Content, mcp.NewTextContent(note))
 	}
+	return result
 }
 
 func CreateMessagesaddTool(cfg *config.APIConfig) models.Tool {
@@ -107,7 +378,8 @@ func CreateMessagesaddTool(cfg *config.APIConfig) models.Tool {
 		mcp.WithString("created_at", mcp.Description("Input parameter: The date and time when the object was created.")),
 		mcp.WithString("subject", mcp.Description("")),
 		mcp.WithString("type", mcp.Description("Input parameter: Set to sms for SMS messages and mms for MMS messages.")),
-		mcp.WithNumber("number_of_media_files", mcp.Description("Input parameter: The number of media files associated with the message.")),
+		mcp.WithNumber("number_of_media_files", mcp.Description("Input parameter: The number of media files associated with the message. Set automatically when media is attached.")),
+		mcp.WithArray("media", mcp.Description("Media to attach, which makes the message an MMS (at most 10 items). The URLs are sent in media_urls, a connector-specific field outside the Unify API: connectors that do not support it send the message without attachments. Each item is an http(s) URL, a base64 data: URL, an object with url or with base64 data and mime_type, or an MCP embedded resource (blob or text with mimeType). Inline content is hosted by the server, which requires HTTP mode and PUBLIC_BASE_URL. Supported types: JPEG, PNG, GIF, MP4, 3GPP, MP3, AMR, vCard, iCalendar, plain text and PDF.")),
 		mcp.WithString("messaging_service_id", mcp.Description("Input parameter: The ID of the Messaging Service used with the message. In case of Plivo this links to the Powerpack ID.")),
 		mcp.WithObject("price", mcp.Description("Input parameter: Price of the message.")),
 		mcp.WithString("body", mcp.Required(), mcp.Description("Input parameter: The message text.")),
@@ -124,7 +396,11 @@ func CreateMessagesaddTool(cfg *config.APIConfig) models.Tool {
 		mcp.WithString("to", mcp.Required(), mcp.Description("Input parameter: The phone number that received the message.")),
 		mcp.WithString("reference", mcp.Description("Input parameter: A client reference.")),
 		mcp.WithString("status", mcp.Description("Input parameter: Status of the delivery of the message.")),
-		mcp.WithString("webhook_url", mcp.Description("Input parameter: Define a webhook to receive delivery notifications.")),
+		mcp.WithString("webhook_url", mcp.Description("Input parameter: Define a webhook to receive delivery notifications. When the server has a public URL configured and this is omitted, the server's own webhook endpoint is used.")),
+		mcp.WithString("idempotency_key", mcp.Description("Key that makes retries safe: repeating a call with the same key returns the original response instead of sending again. Defaults to 'reference'.")),
+		mcp.WithString("route", mcp.Description("Service ID to send through, overriding least-cost routing from ROUTING_PRICES. An explicit x-apideck-service-id also overrides it.")),
+		mcp.WithBoolean("failover", mcp.Description("Try the consumer's other services from FAILOVER_SERVICES when the service fails without sending. Default true; false sends through x-apideck-service-id only.")),
+		mcp.WithString("recipient_timezone", mcp.Description("IANA time zone of the recipient (e.g. Europe/Paris) used for the send window. Inferred from the country code of 'to' when omitted.")),
 	)
 
 	return models.Tool{
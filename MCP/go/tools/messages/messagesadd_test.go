@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/webhooks"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// dropConnection closes the connection after the API read the request, without a response
+const dropConnection = -1
+
+func TestMessagesaddIdempotency(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  map[string]int // Response status per service header
+		failover  []string
+		closed    bool // The API does not accept connections
+		wantFirst string
+		wantRetry string
+		wantSends int32 // Requests that reached the API over both calls
+	}{
+		{
+			name:      "accepted send replays",
+			statuses:  map[string]int{"": 201},
+			wantFirst: `"id": "msg_1"`,
+			wantRetry: `"id": "msg_1"`,
+			wantSends: 1,
+		},
+		{
+			name:      "rejected send is retried",
+			statuses:  map[string]int{"": 400},
+			wantFirst: "API error",
+			wantRetry: "API error",
+			wantSends: 2,
+		},
+		{
+			name:      "unavailable service is retried",
+			statuses:  map[string]int{"": 503},
+			wantFirst: "API error",
+			wantRetry: "API error",
+			wantSends: 2,
+		},
+		{
+			name:      "connection refused is retried",
+			closed:    true,
+			wantFirst: "Request failed",
+			wantRetry: "Request failed",
+		},
+		{
+			name:      "internal error is ambiguous",
+			statuses:  map[string]int{"": 500},
+			wantFirst: "Ambiguous response from the default service, the message may have been sent. Check get_sms_messages",
+			wantRetry: "Ambiguous outcome",
+			wantSends: 1,
+		},
+		{
+			name:      "internal error stops failover",
+			statuses:  map[string]int{"twilio": 500, "plivo": 201},
+			failover:  []string{"twilio", "plivo"},
+			wantFirst: "Ambiguous response from twilio, the message may have been sent; not trying other services",
+			wantRetry: "Ambiguous outcome",
+			wantSends: 1,
+		},
+		{
+			name:      "gateway timeout is ambiguous",
+			statuses:  map[string]int{"": 504},
+			wantFirst: "Ambiguous response from the default service",
+			wantRetry: "Ambiguous outcome",
+			wantSends: 1,
+		},
+		{
+			name:      "dropped connection is ambiguous",
+			statuses:  map[string]int{"": dropConnection},
+			wantFirst: "Ambiguous response",
+			wantRetry: "Ambiguous outcome",
+			wantSends: 1,
+		},
+		{
+			name:      "failover stops at an ambiguous service",
+			statuses:  map[string]int{"twilio": 503, "plivo": 502, "vonage": 201},
+			failover:  []string{"twilio", "plivo", "vonage"},
+			wantFirst: "Ambiguous response from plivo, the message may have been sent; not trying other services",
+			wantRetry: "Ambiguous outcome",
+			wantSends: 2,
+		},
+		{
+			name:      "failover skips an unavailable service",
+			statuses:  map[string]int{"twilio": 429, "plivo": 201},
+			failover:  []string{"twilio", "plivo"},
+			wantFirst: `"id": "msg_1"`,
+			wantRetry: `"id": "msg_1"`,
+			wantSends: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sends atomic.Int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sends.Add(1)
+				status := tt.statuses[r.Header.Get("x-apideck-service-id")]
+				if status == dropConnection {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.WriteHeader(status)
+				if status < 300 {
+					w.Write([]byte(`{"status_code":201,"status":"Created","service":"twilio","resource":"messages","operation":"add","data":{"id":"msg_1"}}`))
+					return
+				}
+				fmt.Fprintf(w, `{"status_code":%d,"error":%q}`, status, http.StatusText(status))
+			}))
+			defer api.Close()
+			if tt.closed {
+				api.Close()
+			}
+
+			cfg := &config.APIConfig{BaseURL: api.URL, DataDir: t.TempDir(), IdempotencyTTL: time.Hour, ScheduleQueue: "off"}
+			if tt.failover != nil {
+				cfg.Failover = map[string][]string{"*": tt.failover}
+			}
+			request := mcp.CallToolRequest{}
+			request.Params.Name = "post_sms_messages"
+			request.Params.Arguments = map[string]any{
+				"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app",
+				"from": "+15550100", "to": "+15550101", "body": "Hello", "idempotency_key": "k1",
+			}
+			for i, want := range []string{tt.wantFirst, tt.wantRetry} {
+				res, err := MessagesaddHandler(cfg)(context.Background(), request)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if text, _ := resultText(res); !strings.Contains(text, want) {
+					t.Fatalf("call %d = %q, want %q", i+1, text, want)
+				}
+			}
+			if got := sends.Load(); got != tt.wantSends {
+				t.Fatalf("API received %d requests, want %d", got, tt.wantSends)
+			}
+		})
+	}
+}
+
+func TestMessagesaddWebhookService(t *testing.T) {
+	var services []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			WebhookURL string `json:"webhook_url"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		u, _ := url.Parse(body.WebhookURL)
+		claims, err := webhooks.ParseToken("secret", u.Query().Get("token"), time.Hour)
+		if err != nil {
+			t.Errorf("webhook token: %v", err)
+		}
+		services = append(services, claims.ServiceId)
+		if r.Header.Get("x-apideck-service-id") == "twilio" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status_code":503,"error":"Service Unavailable"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status_code":201,"status":"Created","service":"plivo","resource":"messages","operation":"add","data":{"id":"msg_1"}}`))
+	}))
+	defer api.Close()
+
+	cfg := &config.APIConfig{
+		BaseURL: api.URL, DataDir: t.TempDir(), ScheduleQueue: "off",
+		PublicURL: "https://sms.example.com", WebhookPath: "/webhooks", WebhookSecret: "secret",
+		Failover: map[string][]string{"*": {"twilio", "plivo"}},
+	}
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "post_sms_messages"
+	request.Params.Arguments = map[string]any{
+		"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app",
+		"from": "+15550100", "to": "+15550101", "body": "Hello",
+	}
+	res, err := MessagesaddHandler(cfg)(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := resultText(res); !strings.Contains(text, `"id": "msg_1"`) {
+		t.Fatalf("result = %q, want the created message", text)
+	}
+	if got := strings.Join(services, ","); got != "twilio,plivo" {
+		t.Fatalf("webhook tokens signed for %q, want each attempted service", got)
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/sms-api/mcp-server/config"
 	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/ratelimit"
+	"github.com/sms-api/mcp-server/suppression"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -19,6 +22,22 @@ func MessagesallHandler(cfg *config.APIConfig) func(ctx context.Context, request
 		if !ok {
 			return mcp.NewToolResultError("Invalid arguments object"), nil
 		}
+		// Several services are listed concurrently and merged
+		if cursor, _ := args["cursor"].(string); len(serviceIdsArg(args)) > 0 || strings.HasPrefix(cursor, fanOutCursorPrefix) {
+			return fanOutMessages(ctx, cfg, request, args)
+		}
+		filter, err := parseMessageFilter(args)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid filter", err), nil
+		}
+		if filter != nil {
+			return filterMessages(ctx, cfg, request, args, filter)
+		}
+		if c := useCache(cfg, args); c != nil {
+			if cursor, _ := args["cursor"].(string); cursor == "" || strings.HasPrefix(cursor, cacheCursorPrefix) {
+				return listCachedMessages(ctx, cfg, request, args, c)
+			}
+		}
 		queryParams := make([]string, 0)
 		if val, ok := args["raw"]; ok {
 			queryParams = append(queryParams, fmt.Sprintf("raw=%v", val))
@@ -57,6 +76,9 @@ func MessagesallHandler(cfg *config.APIConfig) func(ctx context.Context, request
 			req.Header.Set("x-apideck-service-id", fmt.Sprintf("%v", val))
 		}
 
+		if err := ratelimit.Shared(cfg.RateLimit).Wait(ctx); err != nil {
+			return mcp.NewToolResultErrorFromErr("Request cancelled while waiting for the rate limiter", err), nil
+		}
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
@@ -78,6 +100,16 @@ func MessagesallHandler(cfg *config.APIConfig) func(ctx context.Context, request
 			return mcp.NewToolResultText(string(body)), nil
 		}
 
+		// Record STOP/START replies from inbound messages on the suppression list
+		if suppressions, err := suppression.Open(cfg.DataDir); err != nil {
+			log.Printf("Failed to load suppression list: %v", err)
+		} else if added, removed, err := suppressions.ApplyInbound(fmt.Sprintf("%v", args["x-apideck-consumer-id"]), result.Data); err != nil {
+			log.Printf("Failed to update suppression list: %v", err)
+		} else if added > 0 || removed > 0 {
+			log.Printf("Suppression list updated from inbound messages: %d opted out, %d opted back in", added, removed)
+		}
+		indexMessages(cfg, args, result.Data)
+
 		prettyJSON, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
@@ -94,9 +126,21 @@ func CreateMessagesallTool(cfg *config.APIConfig) models.Tool {
 		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
 		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
 		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
-		mcp.WithString("cursor", mcp.Description("Cursor to start from. You can find cursors for next/previous pages in the meta.cursors property of the response.")),
-		mcp.WithNumber("limit", mcp.Description("Number of results to return. Minimum 1, Maximum 200, Default 20")),
+		mcp.WithString("service_ids", mcp.Description("Comma-separated service IDs to list concurrently (e.g. twilio,plivo). Messages are merged newest first and tagged with their service; a failing service is reported in failures without failing the call. limit and filters apply per service")),
+		mcp.WithString("cursor", mcp.Description("Cursor to start from. You can find cursors for next/previous pages in the meta.cursors property of the response, or in next_cursor when listing several services.")),
+		mcp.WithNumber("limit", mcp.Description("Number of results to return. Minimum 1, Maximum 200, Default 20. With filters, the number of matches to collect before stopping")),
 		mcp.WithString("fields", mcp.Description("The 'fields' parameter allows API users to specify the fields they want to include in the API response. If this parameter is not present, the API will return all available fields. If this parameter is present, only the fields specified in the comma-separated string will be included in the response. Nested properties can also be requested by using a dot notation. <br /><br />Example: `fields=name,email,addresses.city`<br /><br />In the example above, the response will only include the fields \"name\", \"email\" and \"addresses.city\". If any other fields are available, they will be excluded.")),
+		mcp.WithString("direction", mcp.Description("Filter: comma-separated directions to keep, e.g. inbound, outbound (any outbound variant) or outbound-api")),
+		mcp.WithString("status", mcp.Description("Filter: comma-separated delivery statuses to keep, e.g. failed,undelivered")),
+		mcp.WithString("type", mcp.Description("Filter: message type to keep, sms or mms")),
+		mcp.WithString("from", mcp.Description("Filter: keep messages sent from this phone number")),
+		mcp.WithString("to", mcp.Description("Filter: keep messages sent to this phone number")),
+		mcp.WithString("since", mcp.Description("Filter: keep messages sent (or created) at or after this RFC 3339 date-time or YYYY-MM-DD date")),
+		mcp.WithString("until", mcp.Description("Filter: keep messages sent (or created) before this RFC 3339 date-time or YYYY-MM-DD date")),
+		mcp.WithString("body_contains", mcp.Description("Filter: keep messages whose body contains this text (case-insensitive)")),
+		mcp.WithString("body_regex", mcp.Description("Filter: keep messages whose body matches this regular expression (Go RE2 syntax)")),
+		mcp.WithString("source", mcp.Description("Where to read from when the message cache is enabled: cache (default, synced first when stale) or api")),
+		mcp.WithNumber("max_scan", mcp.Description("With filters, the maximum number of messages to read before stopping. Default 1000, maximum 10000")),
 	)
 
 	return models.Tool{
@@ -104,3 +148,43 @@ func CreateMessagesallTool(cfg *config.APIConfig) models.Tool {
 		Handler:    MessagesallHandler(cfg),
 	}
 }
+
+// listMessagesPage fetches one unfiltered page through get_sms_messages, so inbound opt-outs are still recorded,
+// along with the notes attached to it.
+func listMessagesPage(ctx context.Context, cfg *config.APIConfig, request mcp.CallToolRequest, args map[string]any, cursor string) (*models.GetMessagesResponse, []string, error) {
+	pageArgs := make(map[string]any, len(args)+1)
+	for key, val := range args {
+		pageArgs[key] = val
+	}
+	delete(pageArgs, "cursor")
+	for _, key := range messageFilterArgs {
+		delete(pageArgs, key)
+	}
+	if cursor != "" {
+		pageArgs["cursor"] = cursor
+	}
+	pageRequest := request
+	pageRequest.Params.Name = "get_sms_messages"
+	pageRequest.Params.Arguments = pageArgs
+	result, err := MessagesallHandler(cfg)(ctx, pageRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	text, notes := resultText(result)
+	if result.IsError {
+		return nil, nil, fmt.Errorf("%s", text)
+	}
+	var page models.GetMessagesResponse
+	if err := json.Unmarshal([]byte(text), &page); err != nil {
+		return nil, nil, fmt.Errorf("unexpected list response: %w", err)
+	}
+	return &page, notes, nil
+}
+
+// nextCursor returns the cursor of the following page, or "" on the last page
+func nextCursor(page *models.GetMessagesResponse) string {
+	if next, ok := page.Meta.Cursors["next"].(string); ok {
+		return next
+	}
+	return ""
+}
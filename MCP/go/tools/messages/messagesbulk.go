@@ -0,0 +1,354 @@
+package tools
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/templates"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultBulkConcurrency = 5
+	maxBulkConcurrency     = 20
+)
+
+// Per-recipient columns that map onto post_sms_messages arguments rather than template variables
+var bulkMessageFields = map[string]bool{
+	"to": true, "from": true, "reference": true, "scheduled_at": true, "messaging_service_id": true,
+	"webhook_url": true, "subject": true, "type": true, "recipient_timezone": true,
+}
+
+// BulkRecipient is one message to send in a bulk request
+type BulkRecipient struct {
+	Fields    map[string]any // post_sms_messages arguments overriding the shared ones
+	Variables map[string]any // Template variables overriding the shared ones
+}
+
+// BulkResult reports the outcome for a single recipient
+type BulkResult struct {
+	Row       int      `json:"row"`
+	To        string   `json:"to"`
+	Status    string   `json:"status"` // "sent", "queued", "failed" or "skipped"
+	MessageId string   `json:"message_id,omitempty"`
+	JobId     string   `json:"job_id,omitempty"` // Server queue job of a queued message, see list_queued_sms
+	Error     string   `json:"error,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+// BulkSummary is the result of post_sms_messages_bulk
+type BulkSummary struct {
+	Total   int          `json:"total"`
+	Sent    int          `json:"sent"`
+	Queued  int          `json:"queued"`
+	Failed  int          `json:"failed"`
+	Skipped int          `json:"skipped"`
+	Results []BulkResult `json:"results"`
+}
+
+// parseBulkRecipients reads recipients from a JSON list (phone numbers or objects) or CSV content with a header row
+func parseBulkRecipients(args map[string]any) ([]BulkRecipient, error) {
+	recipients := make([]BulkRecipient, 0)
+	if val, ok := args["recipients"]; ok && val != nil {
+		list, ok := val.([]any)
+		if !ok {
+			return nil, fmt.Errorf("recipients must be an array")
+		}
+		for i, item := range list {
+			switch v := item.(type) {
+			case string:
+				recipients = append(recipients, BulkRecipient{Fields: map[string]any{"to": v}, Variables: map[string]any{}})
+			case map[string]any:
+				recipient := BulkRecipient{Fields: map[string]any{}, Variables: map[string]any{}}
+				for key, field := range v {
+					if key == "variables" {
+						vars, ok := field.(map[string]any)
+						if !ok {
+							return nil, fmt.Errorf("recipient %d: variables must be an object", i+1)
+						}
+						for name, value := range vars {
+							recipient.Variables[name] = value
+						}
+					} else if bulkMessageFields[key] {
+						recipient.Fields[key] = field
+					} else {
+						recipient.Variables[key] = field
+					}
+				}
+				recipients = append(recipients, recipient)
+			default:
+				return nil, fmt.Errorf("recipient %d must be a phone number or an object", i+1)
+			}
+		}
+	}
+	if content, ok := args["csv"].(string); ok && strings.TrimSpace(content) != "" {
+		reader := csv.NewReader(strings.NewReader(content))
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(rows) < 2 {
+			return nil, fmt.Errorf("CSV needs a header row and at least one recipient")
+		}
+		header := rows[0]
+		for _, row := range rows[1:] {
+			recipient := BulkRecipient{Fields: map[string]any{}, Variables: map[string]any{}}
+			for i, column := range header {
+				column = strings.TrimSpace(column)
+				if i >= len(row) || row[i] == "" {
+					continue
+				}
+				if bulkMessageFields[column] {
+					recipient.Fields[column] = row[i]
+				} else {
+					recipient.Variables[column] = row[i]
+				}
+			}
+			recipients = append(recipients, recipient)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("provide recipients or csv")
+	}
+	for i, recipient := range recipients {
+		if to, _ := recipient.Fields["to"].(string); to == "" {
+			return nil, fmt.Errorf("recipient %d has no 'to' number", i+1)
+		}
+	}
+	return recipients, nil
+}
+
+// notifyProgress sends an MCP progress notification when the client asked for progress updates
+func notifyProgress(ctx context.Context, request mcp.CallToolRequest, progress, total float64, message string) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+	params := map[string]any{
+		"progressToken": request.Params.Meta.ProgressToken,
+		"progress":      progress,
+		"message":       message,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	_ = srv.SendNotificationToClient(ctx, "notifications/progress", params)
+}
+
+// resultText joins the text content of a tool result
+func resultText(result *mcp.CallToolResult) (string, []string) {
+	text := ""
+	notes := make([]string, 0)
+	for i, content := range result.Content {
+		if tc, ok := content.(mcp.TextContent); ok {
+			if i == 0 {
+				text = tc.Text
+			} else {
+				notes = append(notes, tc.Text)
+			}
+		}
+	}
+	return text, notes
+}
+
+func MessagesbulkHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		recipients, err := parseBulkRecipients(args)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid recipients", err), nil
+		}
+
+		body, _ := args["body"].(string)
+		templateName, _ := args["template"].(string)
+		if (body == "") == (templateName == "") {
+			return mcp.NewToolResultError("Provide exactly one of body or template"), nil
+		}
+		var tmpl *templates.Template
+		if templateName != "" {
+			loaded, err := templates.Load(cfg.TemplatesDir)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to load templates", err), nil
+			}
+			if tmpl, ok = loaded[templateName]; !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("Unknown template %q, use list_sms_templates to see the available templates", templateName)), nil
+			}
+		}
+		locale, _ := args["locale"].(string)
+		sharedVars, _ := args["variables"].(map[string]any)
+
+		concurrency := defaultBulkConcurrency
+		if val, ok := args["concurrency"].(float64); ok && val >= 1 {
+			concurrency = min(int(val), maxBulkConcurrency)
+		}
+
+		// Arguments shared by every message; bulk-only arguments are not forwarded
+		shared := make(map[string]any, len(args))
+		for key, val := range args {
+			switch key {
+			case "recipients", "csv", "template", "locale", "variables", "concurrency":
+			default:
+				shared[key] = val
+			}
+		}
+
+		send := MessagesaddHandler(cfg)
+		summary := BulkSummary{Total: len(recipients), Results: make([]BulkResult, len(recipients))}
+		var done atomic.Int64
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < concurrency; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					summary.Results[i] = sendBulkRecipient(ctx, request, send, shared, recipients[i], i+1, body, tmpl, locale, sharedVars)
+					n := done.Add(1)
+					notifyProgress(ctx, request, float64(n), float64(len(recipients)),
+						fmt.Sprintf("%s: %s", summary.Results[i].To, summary.Results[i].Status))
+				}
+			}()
+		}
+		for i := range recipients {
+			if ctx.Err() != nil {
+				summary.Results[i] = BulkResult{Row: i + 1, To: fmt.Sprintf("%v", recipients[i].Fields["to"]), Status: "skipped", Error: "cancelled before sending"}
+				continue
+			}
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		for _, result := range summary.Results {
+			switch result.Status {
+			case "sent":
+				summary.Sent++
+			case "queued":
+				summary.Queued++
+			case "failed":
+				summary.Failed++
+			default:
+				summary.Skipped++
+			}
+		}
+
+		prettyJSON, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return mcp.NewToolResultText(string(prettyJSON)), nil
+	}
+}
+
+// sendBulkRecipient renders the body for one recipient and sends it through post_sms_messages
+func sendBulkRecipient(ctx context.Context, request mcp.CallToolRequest, send func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error),
+	shared map[string]any, recipient BulkRecipient, row int, body string, tmpl *templates.Template, locale string, sharedVars map[string]any) BulkResult {
+	args := make(map[string]any, len(shared)+len(recipient.Fields)+1)
+	for key, val := range shared {
+		args[key] = val
+	}
+	for key, val := range recipient.Fields {
+		args[key] = val
+	}
+	result := BulkResult{Row: row, To: fmt.Sprintf("%v", args["to"])}
+
+	// A shared idempotency key, or a shared reference it defaults to, would make every recipient after
+	// the first a reuse of the key. Each recipient gets its own key derived from it and its row, so
+	// repeating the call still replays every recipient.
+	key, _ := shared["idempotency_key"].(string)
+	if _, own := recipient.Fields["reference"]; key == "" && !own {
+		key, _ = shared["reference"].(string)
+	}
+	if key != "" {
+		args["idempotency_key"] = fmt.Sprintf("%s/%d", key, row)
+	}
+
+	if tmpl != nil {
+		vars := make(map[string]any, len(sharedVars)+len(recipient.Variables))
+		for name, value := range sharedVars {
+			vars[name] = value
+		}
+		for name, value := range recipient.Variables {
+			vars[name] = value
+		}
+		rendered, _, err := tmpl.Render(locale, vars)
+		if err != nil {
+			result.Status, result.Error = "failed", err.Error()
+			return result
+		}
+		body = rendered
+	}
+	args["body"] = body
+
+	sendRequest := request
+	sendRequest.Params.Name = "post_sms_messages"
+	sendRequest.Params.Arguments = args
+	sendRequest.Params.Meta = nil
+	res, err := send(ctx, sendRequest)
+	if err != nil {
+		result.Status, result.Error = "failed", err.Error()
+		return result
+	}
+	text, notes := resultText(res)
+	result.Notes = notes
+	if res.IsError {
+		result.Status, result.Error = "failed", text
+		return result
+	}
+	sent, err := ParseSendResult(text)
+	switch {
+	case err != nil:
+		result.Status, result.Error = "failed", err.Error()
+	case sent.JobId != "":
+		result.Status, result.JobId = "queued", sent.JobId
+	default:
+		result.Status, result.MessageId = "sent", sent.MessageId
+	}
+	return result
+}
+
+func CreateMessagesbulkTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("post_sms_messages_bulk",
+		mcp.WithDescription("Send the same message (or a template rendered per recipient) to many recipients with bounded concurrency. Every message goes through post_sms_messages, so opt-outs, the send window and the rate limit apply. Returns the created message ID, the queue job ID of a message held by the server's queue, or the error per recipient."),
+		mcp.WithArray("recipients", mcp.Description("Recipients as phone numbers, or objects with 'to' plus optional per-recipient fields (from, reference, scheduled_at, messaging_service_id, webhook_url, subject, type, recipient_timezone) and template variables")),
+		mcp.WithString("csv", mcp.Description("Recipients as CSV content with a header row. A 'to' column is required; message field columns override shared values, other columns become template variables.")),
+		mcp.WithString("body", mcp.Description("Message text sent to every recipient. Provide either body or template.")),
+		mcp.WithString("template", mcp.Description("Name of a template rendered per recipient, see list_sms_templates")),
+		mcp.WithString("locale", mcp.Description("Template locale, e.g. fr or pt-BR")),
+		mcp.WithObject("variables", mcp.Description("Template variables shared by all recipients; per-recipient values take precedence")),
+		mcp.WithNumber("concurrency", mcp.Description("Number of messages sent in parallel. Minimum 1, Maximum 20, Default 5")),
+		mcp.WithBoolean("raw", mcp.Description("Include raw response. Mostly used for debugging purposes")),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("from", mcp.Required(), mcp.Description("Input parameter: The phone number that initiated the message.")),
+		mcp.WithString("subject", mcp.Description("")),
+		mcp.WithString("type", mcp.Description("Input parameter: Set to sms for SMS messages and mms for MMS messages.")),
+		mcp.WithString("messaging_service_id", mcp.Description("Input parameter: The ID of the Messaging Service used with the message. In case of Plivo this links to the Powerpack ID.")),
+		mcp.WithString("scheduled_at", mcp.Description("Input parameter: The scheduled date and time of the message.")),
+		mcp.WithString("webhook_url", mcp.Description("Input parameter: Define a webhook to receive delivery notifications.")),
+		mcp.WithString("reference", mcp.Description("Client reference shared by every message, unless a recipient sets its own")),
+		mcp.WithString("idempotency_key", mcp.Description("Key that makes retrying the whole call safe. Each recipient is sent with '<key>/<row>', so repeating the call returns the original results instead of sending again. Defaults to the shared reference for recipients without their own.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesbulkHandler(cfg),
+	}
+}
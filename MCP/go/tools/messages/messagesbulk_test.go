@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMessagesbulkSharedKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       map[string]any
+		recipients []any
+		wantSends  int32 // Requests that reached the API over both calls
+	}{
+		{
+			name:       "shared idempotency key",
+			args:       map[string]any{"idempotency_key": "campaign-1"},
+			recipients: []any{"+15550101", "+15550102", map[string]any{"to": "+15550103", "reference": "vip"}},
+			wantSends:  3,
+		},
+		{
+			name:       "shared reference",
+			args:       map[string]any{"reference": "campaign-1"},
+			recipients: []any{"+15550101", "+15550102", "+15550103"},
+			wantSends:  3,
+		},
+		{
+			name:       "no key sends again",
+			recipients: []any{"+15550101", "+15550102", "+15550103"},
+			wantSends:  6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sends atomic.Int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sends.Add(1)
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"status_code":201,"status":"Created","service":"twilio","resource":"messages","operation":"add","data":{"id":"msg_1"}}`))
+			}))
+			defer api.Close()
+
+			cfg := &config.APIConfig{BaseURL: api.URL, DataDir: t.TempDir(), IdempotencyTTL: time.Hour, ScheduleQueue: "off"}
+			args := map[string]any{
+				"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app",
+				"from": "+15550100", "body": "Hello", "recipients": tt.recipients,
+			}
+			for key, val := range tt.args {
+				args[key] = val
+			}
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			for call := 1; call <= 2; call++ {
+				res, err := MessagesbulkHandler(cfg)(context.Background(), request)
+				if err != nil {
+					t.Fatal(err)
+				}
+				text, _ := resultText(res)
+				var summary BulkSummary
+				if err := json.Unmarshal([]byte(text), &summary); err != nil || summary.Sent != len(tt.recipients) {
+					t.Fatalf("call %d = %s, want every recipient sent", call, text)
+				}
+			}
+			if got := sends.Load(); got != tt.wantSends {
+				t.Fatalf("API received %d requests, want %d", got, tt.wantSends)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// apiErrorStatus returns the status code of the API error response carried by a tool error, or 0
+func apiErrorStatus(text string) int {
+	var apiError struct {
+		StatusCode int    `json:"status_code"`
+		TypeName   string `json:"type_name"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(text, "API error: ")), &apiError); err != nil {
+		return 0
+	}
+	if apiError.TypeName == "NotImplementedError" {
+		return 501
+	}
+	return apiError.StatusCode
+}
+
+// notImplemented reports whether a tool error carries the API's 501 Not Implemented response,
+// returned by connectors that do not support an operation
+func notImplemented(text string) bool {
+	return apiErrorStatus(text) == 501
+}
+
+func MessagescancelHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		id, _ := args["id"].(string)
+		if id == "" {
+			return mcp.NewToolResultError("Missing required parameter: id"), nil
+		}
+		current, err := scheduledMessage(ctx, cfg, request, args, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Cannot cancel", err), nil
+		}
+
+		res, err := patchScheduledMessage(ctx, cfg, request, args, current, map[string]any{"status": "canceled"})
+		if err != nil {
+			return res, err
+		}
+		if !res.IsError {
+			return withNotes(res, []string{fmt.Sprintf("Canceled scheduled message %s", id)}), nil
+		}
+		if text, _ := resultText(res); !notImplemented(text) {
+			return res, nil
+		}
+
+		// The connector cannot update the status; deleting a scheduled message also cancels it
+		deleteArgs := map[string]any{"id": id}
+		for _, key := range connectionArgs {
+			if val, ok := args[key]; ok {
+				deleteArgs[key] = val
+			}
+		}
+		deleteRequest := request
+		deleteRequest.Params.Name = "delete_sms_messages_id"
+		deleteRequest.Params.Arguments = deleteArgs
+		deleteRequest.Params.Meta = nil
+		res, err = MessagesdeleteHandler(cfg)(ctx, deleteRequest)
+		if err != nil || res.IsError {
+			return res, err
+		}
+		return withNotes(res, []string{fmt.Sprintf("The connector does not support canceling through an update, so scheduled message %s was deleted instead", id)}), nil
+	}
+}
+
+func CreateMessagescancelTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("cancel_scheduled_sms",
+		mcp.WithDescription("Cancel a scheduled message before it is sent by setting its status to canceled. When the connector does not support that update (501 Not Implemented), the message is deleted instead."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("id", mcp.Required(), mcp.Description("ID of the scheduled message")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagescancelHandler(cfg),
+	}
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultConversationPages    = 10
+	maxConversationPages        = 50
+	defaultConversationMessages = 100
+)
+
+// ConversationEntry is one message of a reconstructed thread
+type ConversationEntry struct {
+	Id        string `json:"id,omitempty"`
+	Direction string `json:"direction"` // "inbound" (from the counterpart) or "outbound" (from our number)
+	At        string `json:"at,omitempty"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Body      string `json:"body"`
+	Status    string `json:"status,omitempty"`
+}
+
+// Conversation is the result of get_sms_conversation
+type Conversation struct {
+	OurNumber    string              `json:"our_number"`
+	Counterpart  string              `json:"counterpart_number"`
+	PagesScanned int                 `json:"pages_scanned"`
+	Scanned      int                 `json:"messages_scanned"`
+	Matched      int                 `json:"messages_matched"`
+	Omitted      int                 `json:"messages_omitted,omitempty"` // Older messages dropped by max_messages
+	NextCursor   string              `json:"next_cursor,omitempty"`      // Set when pages remain unscanned
+	Messages     []ConversationEntry `json:"messages"`
+	Transcript   string              `json:"transcript"`
+}
+
+func MessagesconversationHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		ours := suppression.NormalizeNumber(fmt.Sprintf("%v", args["our_number"]))
+		theirs := suppression.NormalizeNumber(fmt.Sprintf("%v", args["counterpart_number"]))
+		if ours == "" || theirs == "" {
+			return mcp.NewToolResultError("our_number and counterpart_number are required"), nil
+		}
+		maxPages := defaultConversationPages
+		if val, ok := args["max_pages"].(float64); ok && val >= 1 {
+			maxPages = min(int(val), maxConversationPages)
+		}
+		maxMessages := defaultConversationMessages
+		if val, ok := args["max_messages"].(float64); ok && val >= 1 {
+			maxMessages = int(val)
+		}
+
+		listArgs := map[string]any{"limit": 200}
+		for _, key := range []string{"x-apideck-consumer-id", "x-apideck-app-id", "x-apideck-service-id"} {
+			if val, ok := args[key]; ok {
+				listArgs[key] = val
+			}
+		}
+
+		conversation := Conversation{OurNumber: ours, Counterpart: theirs, Messages: make([]ConversationEntry, 0)}
+		type timedMessage struct {
+			at  time.Time
+			msg models.Message
+		}
+		matched := make([]timedMessage, 0)
+		notes := make([]string, 0)
+		cursor, _ := args["cursor"].(string)
+		for conversation.PagesScanned < maxPages {
+			page, pageNotes, err := listMessagesPage(ctx, cfg, request, listArgs, cursor)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to list messages (page %d)", conversation.PagesScanned+1), err), nil
+			}
+			if conversation.PagesScanned == 0 {
+				notes = append(notes, pageNotes...)
+			}
+			conversation.PagesScanned++
+			conversation.Scanned += len(page.Data)
+			for _, msg := range page.Data {
+				from, to := suppression.NormalizeNumber(msg.From), suppression.NormalizeNumber(msg.To)
+				if (from == ours && to == theirs) || (from == theirs && to == ours) {
+					at, _ := messageTime(msg)
+					matched = append(matched, timedMessage{at: at, msg: msg})
+				}
+			}
+			notifyProgress(ctx, request, float64(conversation.PagesScanned), float64(maxPages),
+				fmt.Sprintf("Scanned %d messages, %d in the conversation", conversation.Scanned, len(matched)))
+			if cursor = nextCursor(page); cursor == "" || len(page.Data) == 0 {
+				cursor = ""
+				break
+			}
+		}
+		conversation.NextCursor = cursor
+		conversation.Matched = len(matched)
+
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].at.Before(matched[j].at) })
+		if len(matched) > maxMessages {
+			conversation.Omitted = len(matched) - maxMessages
+			matched = matched[len(matched)-maxMessages:]
+		}
+
+		var transcript strings.Builder
+		for _, m := range matched {
+			entry := ConversationEntry{Id: m.msg.Id, Direction: "outbound", From: m.msg.From, To: m.msg.To, Body: m.msg.Body, Status: m.msg.Status}
+			speaker := "us"
+			if suppression.NormalizeNumber(m.msg.From) == theirs {
+				entry.Direction = "inbound"
+				speaker = "them"
+			}
+			_, entry.At = messageTime(m.msg)
+			conversation.Messages = append(conversation.Messages, entry)
+
+			stamp := "unknown time"
+			if !m.at.IsZero() {
+				stamp = m.at.UTC().Format("2006-01-02 15:04 UTC")
+			}
+			fmt.Fprintf(&transcript, "[%s] %s: %s\n", stamp, speaker, m.msg.Body)
+		}
+		conversation.Transcript = transcript.String()
+
+		prettyJSON, err := json.MarshalIndent(conversation, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return withNotes(mcp.NewToolResultText(string(prettyJSON)), notes), nil
+	}
+}
+
+func CreateMessagesconversationTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("get_sms_conversation",
+		mcp.WithDescription("Reconstruct the conversation between one of our numbers and a counterpart as a chronological chat transcript"),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("our_number", mcp.Required(), mcp.Description("Our phone number in the conversation")),
+		mcp.WithString("counterpart_number", mcp.Required(), mcp.Description("The other party's phone number")),
+		mcp.WithNumber("max_pages", mcp.Description("Maximum number of message pages (200 messages each) to scan. Default 10, maximum 50")),
+		mcp.WithNumber("max_messages", mcp.Description("Maximum number of most recent conversation messages to return. Default 100")),
+		mcp.WithString("cursor", mcp.Description("Cursor to resume scanning from, taken from next_cursor of a previous call")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesconversationHandler(cfg),
+	}
+}
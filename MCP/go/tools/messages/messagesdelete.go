@@ -10,6 +10,7 @@ import (
 
 	"github.com/sms-api/mcp-server/config"
 	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/ratelimit"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -56,6 +57,9 @@ func MessagesdeleteHandler(cfg *config.APIConfig) func(ctx context.Context, requ
 			req.Header.Set("x-apideck-service-id", fmt.Sprintf("%v", val))
 		}
 
+		if err := ratelimit.Shared(cfg.RateLimit).Wait(ctx); err != nil {
+			return mcp.NewToolResultErrorFromErr("Request cancelled while waiting for the rate limiter", err), nil
+		}
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
@@ -70,6 +74,9 @@ func MessagesdeleteHandler(cfg *config.APIConfig) func(ctx context.Context, requ
 		if resp.StatusCode >= 400 {
 			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
 		}
+		// Drop the deleted message from the cache and the search index
+		invalidateCache(cfg, args, id)
+		unindexMessage(cfg, args, id)
 		// Use properly typed response
 		var result models.DeleteMessageResponse
 		if err := json.Unmarshal(body, &result); err != nil {
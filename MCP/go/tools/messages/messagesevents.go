@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/webhooks"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const maxEventsLimit = 500
+
+// EventsResult is the result of get_sms_events
+type EventsResult struct {
+	Total  int              `json:"total"` // Matching events before the limit
+	Events []webhooks.Event `json:"events"`
+}
+
+func MessageseventsHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		if cfg.WebhookSecret == "" {
+			return mcp.NewToolResultError("The webhook receiver is disabled, set WEBHOOK_SECRET and run in HTTP or HTTPS mode"), nil
+		}
+		events, err := webhooks.Open(cfg.DataDir)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load events", err), nil
+		}
+
+		q := webhooks.Query{Limit: 50}
+		q.ConsumerId, _ = args["x-apideck-consumer-id"].(string)
+		q.MessageId, _ = args["message_id"].(string)
+		q.Type, _ = args["type"].(string)
+		q.Status, _ = args["status"].(string)
+		q.Reference, _ = args["reference"].(string)
+		q.CallId, _ = args["call_id"].(string)
+		if q.Type != "" && q.Type != webhooks.EventDeliveryStatus && q.Type != webhooks.EventInboundMessage {
+			return mcp.NewToolResultError(fmt.Sprintf("Unknown event type %q, expected %s or %s", q.Type, webhooks.EventDeliveryStatus, webhooks.EventInboundMessage)), nil
+		}
+		if val, ok := args["limit"].(float64); ok && val >= 1 {
+			q.Limit = min(int(val), maxEventsLimit)
+		}
+		if q.Since, err = timeArg(args, "since"); err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid since", err), nil
+		}
+
+		matches, total := events.List(q)
+		if includePayload, _ := args["include_payload"].(bool); !includePayload {
+			for i := range matches {
+				matches[i].Payload = nil
+			}
+		}
+
+		prettyJSON, err := json.MarshalIndent(EventsResult{Total: total, Events: matches}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return mcp.NewToolResultText(string(prettyJSON)), nil
+	}
+}
+
+func CreateMessageseventsTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("get_sms_events",
+		mcp.WithDescription("List delivery-status and inbound-message webhook events received by the server, newest first. Messages sent while PUBLIC_BASE_URL is configured report their events here automatically."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer whose events to list")),
+		mcp.WithString("message_id", mcp.Description("Only events about this message")),
+		mcp.WithString("type", mcp.Description("delivery_status or inbound_message")),
+		mcp.WithString("status", mcp.Description("Only events reporting this status, e.g. delivered or failed")),
+		mcp.WithString("reference", mcp.Description("Only events about messages with this client reference")),
+		mcp.WithString("call_id", mcp.Description("Only events for the message sent by the post_sms_messages call with this call ID, reported in its Webhook note")),
+		mcp.WithString("since", mcp.Description("Only events received at or after this RFC 3339 date-time or YYYY-MM-DD date")),
+		mcp.WithNumber("limit", mcp.Description("Number of events to return. Default 50, maximum 500")),
+		mcp.WithBoolean("include_payload", mcp.Description("Include the raw webhook payload of each event")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessageseventsHandler(cfg),
+	}
+}
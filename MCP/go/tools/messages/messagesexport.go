@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/export"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Exports larger than this must be written to a file rather than embedded in the tool result
+const maxEmbeddedExport = 20 << 20
+
+// ExportResult describes a finished export
+type ExportResult struct {
+	Format     string   `json:"format"`
+	Columns    []string `json:"columns"`
+	Rows       int      `json:"rows"`
+	Scanned    int      `json:"scanned"`
+	Bytes      int64    `json:"bytes"`
+	Path       string   `json:"path,omitempty"`
+	URI        string   `json:"uri,omitempty"`
+	Truncated  bool     `json:"truncated"`             // Stopped at max_messages before the end of the list
+	NextCursor string   `json:"next_cursor,omitempty"` // Resume a truncated export here
+}
+
+// countingWriter tracks the number of bytes written and enforces an optional limit
+type countingWriter struct {
+	w     io.Writer
+	n     int64
+	limit int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.limit > 0 && c.n+int64(len(p)) > c.limit {
+		return 0, fmt.Errorf("export exceeds %d MB, use output=file", c.limit>>20)
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// serverFile resolves a path argument naming a file on the server. STDIO clients run on the same
+// machine as the user and may use any path; HTTP clients only name files under DATA_DIR/exports,
+// so they cannot read or overwrite other files the server can access.
+func serverFile(cfg *config.APIConfig, path string) (string, error) {
+	if cfg.Transport == "stdio" {
+		return path, nil
+	}
+	clean := filepath.Clean(path)
+	if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q must be a relative file name in the server's exports directory", path)
+	}
+	return filepath.Join(cfg.DataDir, "exports", clean), nil
+}
+
+func MessagesexportHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		format, _ := args["format"].(string)
+		format = strings.ToLower(format)
+		if format == "" {
+			format = export.FormatCSV
+		}
+		fields, _ := args["fields"].(string)
+		columns := export.Columns(fields)
+		filter, err := parseMessageFilter(args)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid filter", err), nil
+		}
+		maxMessages := 0
+		if val, ok := args["max_messages"].(float64); ok && val >= 1 {
+			maxMessages = int(val)
+		}
+
+		// Local files are only useful to clients running on the same machine
+		output, _ := args["output"].(string)
+		if output == "" {
+			output = "resource"
+			if cfg.Transport == "stdio" {
+				output = "file"
+			}
+		}
+		result := ExportResult{Format: format, Columns: columns}
+		var buffer bytes.Buffer
+		counter := &countingWriter{w: &buffer, limit: maxEmbeddedExport}
+		switch output {
+		case "file":
+			path, _ := args["path"].(string)
+			if path == "" {
+				consumerId, _ := args["x-apideck-consumer-id"].(string)
+				name := fmt.Sprintf("messages-%s-%s%s", consumerId, time.Now().UTC().Format("20060102T150405Z"), export.Extension(format))
+				path = filepath.Join(cfg.DataDir, "exports", filepath.Base(name))
+			} else if path, err = serverFile(cfg, path); err != nil {
+				return mcp.NewToolResultErrorFromErr("Invalid path", err), nil
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to create export directory", err), nil
+			}
+			file, err := os.Create(path)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to create export file", err), nil
+			}
+			defer file.Close()
+			result.Path = path
+			counter = &countingWriter{w: file}
+		case "resource":
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("Unknown output %q, expected file or resource", output)), nil
+		}
+		writer, err := export.NewWriter(format, counter, columns)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid export", err), nil
+		}
+
+		listArgs := map[string]any{}
+		for _, key := range []string{"x-apideck-consumer-id", "x-apideck-app-id", "x-apideck-service-id", "source"} {
+			if val, ok := args[key]; ok {
+				listArgs[key] = val
+			}
+		}
+		// Only fetch the selected fields when no filter needs the others
+		if fields != "" && filter == nil {
+			listArgs["fields"] = fields
+		}
+		cursor, _ := args["cursor"].(string)
+		for {
+			listArgs["limit"] = 200
+			page, _, err := listMessagesPage(ctx, cfg, request, listArgs, cursor)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to list messages after %d rows", result.Rows), err), nil
+			}
+			result.Scanned += len(page.Data)
+			for _, msg := range page.Data {
+				if filter != nil && !filter.Match(msg) {
+					continue
+				}
+				if err := writer.Write(msg); err != nil {
+					return mcp.NewToolResultErrorFromErr("Failed to write export", err), nil
+				}
+				result.Rows++
+			}
+			notifyProgress(ctx, request, float64(result.Rows), 0, fmt.Sprintf("Exported %d of %d scanned messages", result.Rows, result.Scanned))
+			if cursor = nextCursor(page); cursor == "" || len(page.Data) == 0 {
+				cursor = ""
+				break
+			}
+			if maxMessages > 0 && result.Rows >= maxMessages {
+				result.Truncated = true
+				result.NextCursor = cursor
+				break
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to write export", err), nil
+		}
+		result.Bytes = counter.n
+
+		if output == "file" {
+			prettyJSON, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+			}
+			return mcp.NewToolResultText(string(prettyJSON)), nil
+		}
+
+		result.URI = fmt.Sprintf("sms-export://messages-%s%s", time.Now().UTC().Format("20060102T150405Z"), export.Extension(format))
+		prettyJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+		var contents mcp.ResourceContents
+		if format == export.FormatParquet {
+			contents = mcp.BlobResourceContents{URI: result.URI, MIMEType: export.MIMEType(format), Blob: base64.StdEncoding.EncodeToString(buffer.Bytes())}
+		} else {
+			contents = mcp.TextResourceContents{URI: result.URI, MIMEType: export.MIMEType(format), Text: buffer.String()}
+		}
+		return mcp.NewToolResultResource(string(prettyJSON), contents), nil
+	}
+}
+
+func CreateMessagesexportTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("export_sms_messages",
+		mcp.WithDescription("Export message history as CSV, NDJSON or Parquet, written to a file (STDIO) or returned as an embedded resource (HTTP)"),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("format", mcp.Description("csv (default), ndjson or parquet")),
+		mcp.WithString("fields", mcp.Description("Comma-separated columns to export, with dot notation for nested properties (e.g. id,to,status,price.total_amount). Defaults to the common message fields")),
+		mcp.WithString("output", mcp.Description("file or resource. Defaults to file in STDIO mode and resource in HTTP/HTTPS mode")),
+		mcp.WithString("path", mcp.Description("File to write when output is file. Defaults to a timestamped file in the server's exports directory; in HTTP/HTTPS mode only a relative name in that directory is accepted")),
+		mcp.WithNumber("max_messages", mcp.Description("Stop after roughly this many rows (whole pages are written); the result reports a cursor to resume from")),
+		mcp.WithString("cursor", mcp.Description("Cursor to start from, e.g. next_cursor of a truncated export")),
+		mcp.WithString("direction", mcp.Description("Filter: comma-separated directions to keep, e.g. inbound or outbound")),
+		mcp.WithString("status", mcp.Description("Filter: comma-separated delivery statuses to keep")),
+		mcp.WithString("since", mcp.Description("Filter: keep messages sent (or created) at or after this RFC 3339 date-time or YYYY-MM-DD date")),
+		mcp.WithString("until", mcp.Description("Filter: keep messages sent (or created) before this RFC 3339 date-time or YYYY-MM-DD date")),
+		mcp.WithString("source", mcp.Description("Where to read from when the message cache is enabled: cache (default) or api")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesexportHandler(cfg),
+	}
+}
@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sms-api/mcp-server/config"
+)
+
+func TestServerFile(t *testing.T) {
+	dataDir := t.TempDir()
+	tests := []struct {
+		transport string
+		path      string
+		want      string // "" when the path is rejected
+	}{
+		{"stdio", "/tmp/messages.csv", "/tmp/messages.csv"},
+		{"stdio", "../messages.csv", "../messages.csv"},
+		{"http", "messages.csv", filepath.Join(dataDir, "exports", "messages.csv")},
+		{"http", "2025/./messages.csv", filepath.Join(dataDir, "exports", "2025", "messages.csv")},
+		{"https", "a/../messages.csv", filepath.Join(dataDir, "exports", "messages.csv")},
+		{"http", "/etc/passwd", ""},
+		{"http", "../queue.json", ""},
+		{"http", "a/../../queue.json", ""},
+		{"http", "..", ""},
+		{"http", ".", ""},
+	}
+	for _, tt := range tests {
+		cfg := &config.APIConfig{Transport: tt.transport, DataDir: dataDir}
+		got, err := serverFile(cfg, tt.path)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("serverFile(%s, %q) = %q, want an error", tt.transport, tt.path, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("serverFile(%s, %q) = %q, %v, want %q", tt.transport, tt.path, got, err, tt.want)
+		}
+	}
+}
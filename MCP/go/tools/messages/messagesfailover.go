@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/sms-api/mcp-server/config"
+)
+
+// failoverServices returns the services to try in order for a send. Without a failover list the
+// send goes to the requested service only; "" stands for the consumer's default service.
+func failoverServices(cfg *config.APIConfig, args map[string]any, requested string) []string {
+	if enabled, ok := args["failover"].(bool); ok && !enabled {
+		return []string{requested}
+	}
+	services := consumerServices(cfg, args)
+	if len(services) == 0 {
+		return []string{requested}
+	}
+	if requested == "" {
+		return services
+	}
+	// A requested service goes first, the rest of the list backs it up
+	ordered := []string{requested}
+	for _, service := range services {
+		if service != requested {
+			ordered = append(ordered, service)
+		}
+	}
+	return ordered
+}
+
+// consumerServices returns the consumer's services from FAILOVER_SERVICES, the only services known to
+// be connected for it
+func consumerServices(cfg *config.APIConfig, args map[string]any) []string {
+	services, ok := cfg.Failover[fmt.Sprintf("%v", args["x-apideck-consumer-id"])]
+	if !ok {
+		services = cfg.Failover["*"]
+	}
+	return services
+}
+
+// failoverStatus reports whether an API error status proves the message was not sent, so the next
+// service can be tried: rate limiting, unsupported operations and unavailability. An internal error
+// may follow an accepted create, so it is ambiguous like other server errors.
+func failoverStatus(code int) bool {
+	return code == 429 || code == 501 || code == 503
+}
+
+// requestNotSent reports whether a request failed before reaching the API, when connecting.
+// Any later failure leaves it unknown whether the message was accepted.
+func requestNotSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// ambiguousPrefix starts the errors of sends that may have reached the API, so callers and the
+// queue can tell them from failures that prove nothing was sent
+const ambiguousPrefix = "Ambiguous"
+
+// ambiguousSend describes a send whose outcome is unknown; with several services it also explains
+// why the others were not tried
+func ambiguousSend(serviceId string, services int) string {
+	text := fmt.Sprintf("%s response from %s, the message may have been sent", ambiguousPrefix, serviceName(serviceId))
+	if services > 1 {
+		text += "; not trying other services so it is not sent twice"
+	}
+	return text + ". Check get_sms_messages before sending again"
+}
+
+// serviceName names a service in notes, including the consumer's default service
+func serviceName(serviceId string) string {
+	if serviceId == "" {
+		return "the default service"
+	}
+	return serviceId
+}
+
+// failoverNotes reports the services that failed before the last attempt
+func failoverNotes(failures []string) []string {
+	notes := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		notes = append(notes, "Failover: "+failure)
+	}
+	return notes
+}
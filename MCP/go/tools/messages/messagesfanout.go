@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Services queried at the same time by a fan-out; the shared rate limiter still applies
+const maxFanOutConcurrency = 4
+
+// Cursors of a fan-out listing carry the next cursor of every service with pages left
+const fanOutCursorPrefix = "fanout:"
+
+// ServiceFailure reports a service that could not be queried in a fan-out
+type ServiceFailure struct {
+	Service string `json:"service"`
+	Error   string `json:"error"`
+}
+
+// ServiceMessage is a message tagged with the service it was listed from
+type ServiceMessage struct {
+	Service string `json:"service"`
+	models.Message
+}
+
+// ServicePage summarizes the page listed from one service
+type ServicePage struct {
+	Messages   int    `json:"messages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// FanOutMessages is the result of get_sms_messages across several services
+type FanOutMessages struct {
+	Data       []ServiceMessage        `json:"data"`
+	Services   map[string]*ServicePage `json:"services"`
+	Failures   []ServiceFailure        `json:"failures"`
+	NextCursor string                  `json:"next_cursor,omitempty"` // Continues every service with pages left, including failed ones
+}
+
+// serviceIdsArg reads the comma-separated service_ids argument
+func serviceIdsArg(args map[string]any) []string {
+	serviceIds := make([]string, 0)
+	val, _ := args["service_ids"].(string)
+	for _, id := range strings.Split(val, ",") {
+		if id = strings.TrimSpace(id); id != "" && !containsString(serviceIds, id) {
+			serviceIds = append(serviceIds, id)
+		}
+	}
+	return serviceIds
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fanOut runs query for every service concurrently and returns the failures in service order
+func fanOut(ctx context.Context, serviceIds []string, query func(ctx context.Context, serviceId string) error) []ServiceFailure {
+	errs := make([]error, len(serviceIds))
+	slots := make(chan struct{}, maxFanOutConcurrency)
+	var wg sync.WaitGroup
+	for i, serviceId := range serviceIds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				errs[i] = query(ctx, serviceId)
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+			}
+		}()
+	}
+	wg.Wait()
+	failures := make([]ServiceFailure, 0)
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, ServiceFailure{Service: serviceIds[i], Error: err.Error()})
+		}
+	}
+	return failures
+}
+
+func encodeFanOutCursor(cursors map[string]string) string {
+	if len(cursors) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(cursors)
+	return fanOutCursorPrefix + base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFanOutCursor(cursor string) (map[string]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(cursor, fanOutCursorPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	cursors := map[string]string{}
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return cursors, nil
+}
+
+// fanOutMessages lists messages from several services concurrently through get_sms_messages and merges them,
+// newest first. Filters, the cache and limit apply per service.
+func fanOutMessages(ctx context.Context, cfg *config.APIConfig, request mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, error) {
+	serviceIds := serviceIdsArg(args)
+	cursors := map[string]string{}
+	if cursor, _ := args["cursor"].(string); strings.HasPrefix(cursor, fanOutCursorPrefix) {
+		var err error
+		if cursors, err = decodeFanOutCursor(cursor); err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid cursor", err), nil
+		}
+		// Continue only the services that have pages left
+		remaining := make([]string, 0, len(cursors))
+		for _, id := range serviceIds {
+			if _, ok := cursors[id]; ok {
+				remaining = append(remaining, id)
+			}
+		}
+		if len(serviceIds) == 0 {
+			for id := range cursors {
+				remaining = append(remaining, id)
+			}
+			sort.Strings(remaining)
+		}
+		serviceIds = remaining
+	}
+	if len(serviceIds) == 0 {
+		return mcp.NewToolResultError("No service left to list; the cursor covers none of service_ids"), nil
+	}
+
+	result := FanOutMessages{Data: make([]ServiceMessage, 0), Services: map[string]*ServicePage{}}
+	next := map[string]string{}
+	notes := make([]string, 0)
+	var mu sync.Mutex
+	result.Failures = fanOut(ctx, serviceIds, func(ctx context.Context, serviceId string) error {
+		serviceArgs := make(map[string]any, len(args))
+		for key, val := range args {
+			serviceArgs[key] = val
+		}
+		delete(serviceArgs, "service_ids")
+		delete(serviceArgs, "cursor")
+		serviceArgs["x-apideck-service-id"] = serviceId
+		if cursor := cursors[serviceId]; cursor != "" {
+			serviceArgs["cursor"] = cursor
+		}
+		serviceRequest := request
+		serviceRequest.Params.Arguments = serviceArgs
+		serviceRequest.Params.Meta = nil
+		res, err := MessagesallHandler(cfg)(ctx, serviceRequest)
+		if err != nil {
+			return err
+		}
+		text, serviceNotes := resultText(res)
+		if res.IsError {
+			return fmt.Errorf("%s", text)
+		}
+		var page models.GetMessagesResponse
+		if err := json.Unmarshal([]byte(text), &page); err != nil {
+			return fmt.Errorf("unexpected list response: %w", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, msg := range page.Data {
+			result.Data = append(result.Data, ServiceMessage{Service: serviceId, Message: msg})
+		}
+		summary := &ServicePage{Messages: len(page.Data), NextCursor: nextCursor(&page)}
+		result.Services[serviceId] = summary
+		if summary.NextCursor != "" {
+			next[serviceId] = summary.NextCursor
+		}
+		for _, note := range serviceNotes {
+			notes = append(notes, fmt.Sprintf("[%s] %s", serviceId, note))
+		}
+		return nil
+	})
+	if len(result.Failures) == len(serviceIds) {
+		failures, _ := json.Marshal(result.Failures)
+		return mcp.NewToolResultError(fmt.Sprintf("Every service failed: %s", failures)), nil
+	}
+	// Failed services are retried from the same cursor on the next page
+	for _, failure := range result.Failures {
+		next[failure.Service] = cursors[failure.Service]
+	}
+	result.NextCursor = encodeFanOutCursor(next)
+
+	order := map[string]int{}
+	for i, id := range serviceIds {
+		order[id] = i
+	}
+	sort.SliceStable(result.Data, func(i, j int) bool {
+		a, _ := messageTime(result.Data[i].Message)
+		b, _ := messageTime(result.Data[j].Message)
+		if a.Equal(b) {
+			return order[result.Data[i].Service] < order[result.Data[j].Service]
+		}
+		return a.After(b)
+	})
+	sort.Strings(notes)
+
+	prettyJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+	}
+
+	if len(result.Failures) > 0 {
+		notes = append(notes, fmt.Sprintf("%d of %d services failed, see failures; their results are missing", len(result.Failures), len(serviceIds)))
+	}
+	return withNotes(mcp.NewToolResultText(string(prettyJSON)), notes), nil
+}
@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultFilterLimit = 20
+	defaultMaxScan     = 1000
+	maxMaxScan         = 10000
+)
+
+// Arguments of get_sms_messages that are evaluated locally rather than sent to the API
+var messageFilterArgs = []string{"direction", "status", "type", "from", "to", "since", "until", "body_contains", "body_regex", "max_scan"}
+
+// MessageFilter selects messages locally while paging through the list endpoint
+type MessageFilter struct {
+	Directions   map[string]bool
+	Statuses     map[string]bool
+	Types        map[string]bool
+	From         string
+	To           string
+	Since        time.Time
+	Until        time.Time
+	BodyContains string
+	BodyRegex    *regexp.Regexp
+}
+
+// FilterReport describes how much of the message list a filtered call looked at
+type FilterReport struct {
+	PagesScanned   int    `json:"pages_scanned"`
+	Scanned        int    `json:"scanned"`
+	Matched        int    `json:"matched"`
+	ScanCapReached bool   `json:"scan_cap_reached"`
+	NextCursor     string `json:"next_cursor,omitempty"` // Resume scanning here; empty once the list is exhausted
+}
+
+// messageTime orders messages by sent_at, falling back to created_at
+func messageTime(msg models.Message) (time.Time, string) {
+	for _, value := range []string{msg.Sent_at, msg.Created_at} {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, value
+		}
+	}
+	return time.Time{}, ""
+}
+
+// timeArg parses an optional RFC 3339 date-time or YYYY-MM-DD date argument
+func timeArg(args map[string]any, key string) (time.Time, error) {
+	val, ok := args[key].(string)
+	if !ok || val == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, val); err != nil {
+			return time.Time{}, fmt.Errorf("%s must be an RFC 3339 date-time or a YYYY-MM-DD date, got %q", key, val)
+		}
+	}
+	return t, nil
+}
+
+// stringSet splits a comma-separated argument into a lower-case set
+func stringSet(value any) map[string]bool {
+	set := map[string]bool{}
+	s, _ := value.(string)
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			set[part] = true
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
+
+// parseMessageFilter returns the filter described by args, or nil when no filter argument is set
+func parseMessageFilter(args map[string]any) (*MessageFilter, error) {
+	present := false
+	for _, key := range messageFilterArgs {
+		if val, ok := args[key]; ok && val != nil && val != "" {
+			present = true
+		}
+	}
+	if !present {
+		return nil, nil
+	}
+	filter := &MessageFilter{
+		Directions: stringSet(args["direction"]),
+		Statuses:   stringSet(args["status"]),
+		Types:      stringSet(args["type"]),
+	}
+	if val, ok := args["from"].(string); ok {
+		filter.From = suppression.NormalizeNumber(val)
+	}
+	if val, ok := args["to"].(string); ok {
+		filter.To = suppression.NormalizeNumber(val)
+	}
+	var err error
+	if filter.Since, err = timeArg(args, "since"); err != nil {
+		return nil, err
+	}
+	if filter.Until, err = timeArg(args, "until"); err != nil {
+		return nil, err
+	}
+	if val, ok := args["body_contains"].(string); ok {
+		filter.BodyContains = strings.ToLower(val)
+	}
+	if val, ok := args["body_regex"].(string); ok && val != "" {
+		re, err := regexp.Compile(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body_regex: %w", err)
+		}
+		filter.BodyRegex = re
+	}
+	return filter, nil
+}
+
+// Match reports whether msg satisfies every condition of the filter
+func (f *MessageFilter) Match(msg models.Message) bool {
+	if f.Directions != nil {
+		// "outbound" covers the outbound-api, outbound-call and outbound-reply variants
+		direction := strings.ToLower(msg.Direction)
+		base, _, _ := strings.Cut(direction, "-")
+		if !f.Directions[direction] && !f.Directions[base] {
+			return false
+		}
+	}
+	if f.Statuses != nil && !f.Statuses[strings.ToLower(msg.Status)] {
+		return false
+	}
+	if f.Types != nil && !f.Types[strings.ToLower(msg.TypeField)] {
+		return false
+	}
+	if f.From != "" && suppression.NormalizeNumber(msg.From) != f.From {
+		return false
+	}
+	if f.To != "" && suppression.NormalizeNumber(msg.To) != f.To {
+		return false
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		at, _ := messageTime(msg)
+		if at.IsZero() || (!f.Since.IsZero() && at.Before(f.Since)) || (!f.Until.IsZero() && !at.Before(f.Until)) {
+			return false
+		}
+	}
+	if f.BodyContains != "" && !strings.Contains(strings.ToLower(msg.Body), f.BodyContains) {
+		return false
+	}
+	if f.BodyRegex != nil && !f.BodyRegex.MatchString(msg.Body) {
+		return false
+	}
+	return true
+}
+
+// filterMessages pages through the list endpoint, keeping matching messages until limit matches are
+// found or max_scan messages were read. Whole pages are always evaluated so that resuming from the
+// returned cursor never skips a match; the result may therefore hold more than limit messages.
+func filterMessages(ctx context.Context, cfg *config.APIConfig, request mcp.CallToolRequest, args map[string]any, filter *MessageFilter) (*mcp.CallToolResult, error) {
+	limit := defaultFilterLimit
+	if val, ok := args["limit"].(float64); ok && val >= 1 {
+		limit = int(val)
+	}
+	maxScan := defaultMaxScan
+	if val, ok := args["max_scan"].(float64); ok && val >= 1 {
+		maxScan = min(int(val), maxMaxScan)
+	}
+
+	pageArgs := make(map[string]any, len(args))
+	for key, val := range args {
+		pageArgs[key] = val
+	}
+
+	var result *models.GetMessagesResponse
+	report := FilterReport{}
+	matched := make([]models.Message, 0)
+	notes := make([]string, 0)
+	cursor, _ := args["cursor"].(string)
+	for {
+		pageArgs["limit"] = min(200, maxScan-report.Scanned)
+		page, pageNotes, err := listMessagesPage(ctx, cfg, request, pageArgs, cursor)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to list messages (page %d)", report.PagesScanned+1), err), nil
+		}
+		if report.PagesScanned == 0 {
+			notes = append(notes, pageNotes...)
+		}
+		result = page
+		report.PagesScanned++
+		report.Scanned += len(page.Data)
+		for _, msg := range page.Data {
+			if filter.Match(msg) {
+				matched = append(matched, msg)
+			}
+		}
+		notifyProgress(ctx, request, float64(report.Scanned), float64(maxScan),
+			fmt.Sprintf("Scanned %d messages, %d matched", report.Scanned, len(matched)))
+		if cursor = nextCursor(page); cursor == "" || len(page.Data) == 0 {
+			cursor = ""
+			break
+		}
+		if len(matched) >= limit {
+			break
+		}
+		if report.Scanned >= maxScan {
+			report.ScanCapReached = true
+			break
+		}
+	}
+	report.Matched = len(matched)
+	report.NextCursor = cursor
+
+	result.Data = matched
+	result.Meta.Items_on_page = len(matched)
+	result.Meta.Cursors = map[string]interface{}{"next": nil}
+	if cursor != "" {
+		result.Meta.Cursors["next"] = cursor
+	}
+
+	prettyJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+	}
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+	}
+
+	return withNotes(mcp.NewToolResultText(string(prettyJSON)), append([]string{"Filter: " + string(reportJSON)}, notes...)), nil
+}
@@ -0,0 +1,460 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Message fields an import row can set; everything else in a row (ids, statuses, prices) is ignored
+var importMessageFields = map[string]bool{
+	"to": true, "from": true, "body": true, "subject": true, "type": true, "messaging_service_id": true,
+	"scheduled_at": true, "webhook_url": true, "reference": true,
+}
+
+var (
+	phoneNumberPattern = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+	senderIdPattern    = regexp.MustCompile(`^[A-Za-z0-9 ]{1,11}$`)
+)
+
+// ImportRow is one data row of an import file; Row counts data rows from 1, excluding the CSV header
+type ImportRow struct {
+	Row    int
+	Values map[string]string
+}
+
+// ImportFailure reports a row that failed validation or could not be created
+type ImportFailure struct {
+	Row   int               `json:"row"`
+	Error string            `json:"error"`
+	Data  map[string]string `json:"data"`
+}
+
+// ImportCreated links a row to the message created from it, or to the queue job holding it
+type ImportCreated struct {
+	Row       int    `json:"row"`
+	SourceId  string `json:"source_id,omitempty"` // id column of the row, e.g. the message ID at the previous provider
+	MessageId string `json:"message_id,omitempty"`
+	JobId     string `json:"job_id,omitempty"` // Server queue job of a queued row, see list_queued_sms
+}
+
+// ImportReport is the result of import_sms_messages
+type ImportReport struct {
+	Format       string          `json:"format"`
+	DryRun       bool            `json:"dry_run"`
+	Rows         int             `json:"rows"`      // Data rows in the file
+	Processed    int             `json:"processed"` // Rows handled in this run, starting at start_row
+	Valid        int             `json:"valid"`
+	Created      int             `json:"created"`
+	Queued       int             `json:"queued"`
+	Skipped      int             `json:"skipped"`
+	Failed       int             `json:"failed"`
+	NextRow      int             `json:"next_row,omitempty"` // Resume an interrupted or limited import with start_row
+	Messages     []ImportCreated `json:"messages"`
+	Failures     []ImportFailure `json:"failures"`
+	FailuresPath string          `json:"failures_path,omitempty"`
+	Ignored      []string        `json:"ignored_columns,omitempty"`
+}
+
+// readImportRows parses CSV (with a header row) or NDJSON content into rows and the ordered column names
+func readImportRows(format string, r io.Reader) ([]ImportRow, []string, error) {
+	rows := make([]ImportRow, 0)
+	switch format {
+	case "csv":
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(records) == 0 {
+			return nil, nil, fmt.Errorf("CSV needs a header row")
+		}
+		header := records[0]
+		for i := range header {
+			header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+		}
+		for i, record := range records[1:] {
+			values := make(map[string]string, len(header))
+			for j, column := range header {
+				if j < len(record) && record[j] != "" {
+					values[column] = record[j]
+				}
+			}
+			rows = append(rows, ImportRow{Row: i + 1, Values: values})
+		}
+		return rows, header, nil
+	case "ndjson":
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 10<<20)
+		seen := map[string]bool{}
+		columns := make([]string, 0)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			var record map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				return nil, nil, fmt.Errorf("invalid NDJSON on line %d: %w", line, err)
+			}
+			values := make(map[string]string, len(record))
+			for key, value := range record {
+				if text := importText(value); text != "" {
+					values[key] = text
+				}
+				if !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+			rows = append(rows, ImportRow{Row: len(rows) + 1, Values: values})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, nil, err
+		}
+		sort.Strings(columns)
+		return rows, columns, nil
+	}
+	return nil, nil, fmt.Errorf("unknown format %q, expected csv or ndjson", format)
+}
+
+// importText renders an NDJSON value as a cell, encoding objects and lists as JSON
+func importText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// importFormat picks the format from the argument, the file extension or the first character of the content
+func importFormat(format, path, content string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".csv":
+		return "csv"
+	}
+	if strings.HasPrefix(strings.TrimSpace(content), "{") {
+		return "ndjson"
+	}
+	return "csv"
+}
+
+// mapImportRow maps a row's columns onto message fields, applying the column mapping and the defaults
+func mapImportRow(row ImportRow, mapping map[string]string, defaults map[string]any) (models.Message, error) {
+	fields := make(map[string]any, len(importMessageFields))
+	for key, val := range defaults {
+		fields[key] = val
+	}
+	for column, value := range row.Values {
+		field := column
+		if mapped, ok := mapping[column]; ok {
+			field = mapped
+		}
+		if importMessageFields[field] {
+			fields[field] = strings.TrimSpace(value)
+		}
+	}
+	var msg models.Message
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return msg, err
+	}
+	err = json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// validateImportMessage checks a mapped message before it is sent
+func validateImportMessage(msg models.Message, now time.Time) error {
+	if !phoneNumberPattern.MatchString(suppression.NormalizeNumber(msg.To)) {
+		return fmt.Errorf("to %q is not a phone number", msg.To)
+	}
+	if msg.From == "" {
+		return fmt.Errorf("from is missing")
+	}
+	if !phoneNumberPattern.MatchString(suppression.NormalizeNumber(msg.From)) && !senderIdPattern.MatchString(msg.From) {
+		return fmt.Errorf("from %q is neither a phone number nor an alphanumeric sender ID", msg.From)
+	}
+	if strings.TrimSpace(msg.Body) == "" {
+		return fmt.Errorf("body is empty")
+	}
+	if msg.TypeField != "" && msg.TypeField != "sms" && msg.TypeField != "mms" {
+		return fmt.Errorf("type %q must be sms or mms", msg.TypeField)
+	}
+	if msg.Scheduled_at != "" {
+		at, err := time.Parse(time.RFC3339, msg.Scheduled_at)
+		if err != nil {
+			return fmt.Errorf("scheduled_at %q is not an RFC 3339 date-time", msg.Scheduled_at)
+		}
+		if !at.After(now) {
+			return fmt.Errorf("scheduled_at %s is in the past", msg.Scheduled_at)
+		}
+	}
+	if msg.Webhook_url != "" {
+		if u, err := url.Parse(msg.Webhook_url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook_url %q is not an http(s) URL", msg.Webhook_url)
+		}
+	}
+	return nil
+}
+
+// writeImportFailures writes the failed rows with their original columns and an error column, ready to fix and re-import
+func writeImportFailures(path string, columns []string, failures []ImportFailure) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	if err := w.Write(append([]string{"row", "error"}, columns...)); err != nil {
+		return err
+	}
+	for _, failure := range failures {
+		record := []string{fmt.Sprint(failure.Row), failure.Error}
+		for _, column := range columns {
+			record = append(record, failure.Data[column])
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func MessagesimportHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		content, _ := args["content"].(string)
+		path, _ := args["path"].(string)
+		if (content == "") == (path == "") {
+			return mcp.NewToolResultError("Provide exactly one of content or path"), nil
+		}
+		var source io.Reader = strings.NewReader(content)
+		if path != "" {
+			local, err := serverFile(cfg, path)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Invalid path", err), nil
+			}
+			file, err := os.Open(local)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to open import file", err), nil
+			}
+			defer file.Close()
+			source = file
+		}
+		format, _ := args["format"].(string)
+		format = importFormat(format, path, content)
+		rows, columns, err := readImportRows(format, source)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid import file", err), nil
+		}
+
+		mapping := map[string]string{}
+		if val, ok := args["mapping"].(map[string]any); ok {
+			for column, field := range val {
+				name, _ := field.(string)
+				if !importMessageFields[name] {
+					return mcp.NewToolResultError(fmt.Sprintf("Cannot map column %q onto %q, message fields are to, from, body, subject, type, messaging_service_id, scheduled_at, webhook_url and reference", column, name)), nil
+				}
+				mapping[column] = name
+			}
+		}
+		defaults := map[string]any{}
+		for _, key := range []string{"from", "webhook_url", "messaging_service_id", "type"} {
+			if val, ok := args[key].(string); ok && val != "" {
+				defaults[key] = val
+			}
+		}
+		dryRun, _ := args["dry_run"].(bool)
+		onlyScheduled, _ := args["only_scheduled"].(bool)
+		startRow := 1
+		if val, ok := args["start_row"].(float64); ok && val >= 1 {
+			startRow = int(val)
+		}
+		maxRows := 0
+		if val, ok := args["max_rows"].(float64); ok && val >= 1 {
+			maxRows = int(val)
+		}
+
+		report := ImportReport{Format: format, DryRun: dryRun, Rows: len(rows), Messages: make([]ImportCreated, 0), Failures: make([]ImportFailure, 0)}
+		for _, column := range columns {
+			field := column
+			if mapped, ok := mapping[column]; ok {
+				field = mapped
+			}
+			if !importMessageFields[field] && field != "id" {
+				report.Ignored = append(report.Ignored, column)
+			}
+		}
+
+		consumerId := fmt.Sprintf("%v", args["x-apideck-consumer-id"])
+		suppressions, err := suppression.Open(cfg.DataDir)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load suppression list", err), nil
+		}
+		send := MessagesaddHandler(cfg)
+		now := time.Now()
+		for _, row := range rows {
+			if row.Row < startRow {
+				continue
+			}
+			if ctx.Err() != nil || (maxRows > 0 && report.Processed >= maxRows) {
+				report.NextRow = row.Row
+				break
+			}
+			report.Processed++
+			fail := func(err string) {
+				report.Failed++
+				report.Failures = append(report.Failures, ImportFailure{Row: row.Row, Error: err, Data: row.Values})
+			}
+
+			msg, err := mapImportRow(row, mapping, defaults)
+			if err != nil {
+				fail(err.Error())
+				continue
+			}
+			if onlyScheduled {
+				at, err := time.Parse(time.RFC3339, msg.Scheduled_at)
+				if msg.Scheduled_at == "" || (err == nil && !at.After(now)) {
+					report.Skipped++
+					continue
+				}
+			}
+			if err := validateImportMessage(msg, now); err != nil {
+				fail(err.Error())
+				continue
+			}
+			if _, suppressed, err := suppressions.Contains(consumerId, msg.To); err != nil {
+				fail(fmt.Sprintf("cannot check the suppression list: %v", err))
+				continue
+			} else if suppressed {
+				fail(fmt.Sprintf("recipient %s has opted out of messages", msg.To))
+				continue
+			}
+			report.Valid++
+			if dryRun {
+				continue
+			}
+
+			sendArgs := map[string]any{}
+			data, _ := json.Marshal(msg)
+			_ = json.Unmarshal(data, &sendArgs)
+			for _, key := range []string{"x-apideck-consumer-id", "x-apideck-app-id", "x-apideck-service-id"} {
+				if val, ok := args[key]; ok {
+					sendArgs[key] = val
+				}
+			}
+			// Rows carrying their original message ID can be replayed without creating duplicates
+			sourceId := row.Values["id"]
+			if sourceId != "" && msg.Reference == "" {
+				sendArgs["idempotency_key"] = "import/" + sourceId
+			}
+			sendRequest := request
+			sendRequest.Params.Name = "post_sms_messages"
+			sendRequest.Params.Arguments = sendArgs
+			sendRequest.Params.Meta = nil
+			res, err := send(ctx, sendRequest)
+			if err != nil {
+				fail(err.Error())
+				continue
+			}
+			text, _ := resultText(res)
+			if res.IsError {
+				fail(text)
+				continue
+			}
+			sent, err := ParseSendResult(text)
+			if err != nil {
+				fail(err.Error())
+				continue
+			}
+			report.Messages = append(report.Messages, ImportCreated{Row: row.Row, SourceId: sourceId, MessageId: sent.MessageId, JobId: sent.JobId})
+			progress := fmt.Sprintf("Row %d: created %s", row.Row, sent.MessageId)
+			if sent.JobId != "" {
+				report.Queued++
+				progress = fmt.Sprintf("Row %d: queued as %s", row.Row, sent.JobId)
+			} else {
+				report.Created++
+			}
+			notifyProgress(ctx, request, float64(report.Processed), float64(len(rows)-startRow+1), progress)
+		}
+
+		if failuresPath, _ := args["failures_path"].(string); failuresPath != "" && len(report.Failures) > 0 {
+			if failuresPath, err = serverFile(cfg, failuresPath); err != nil {
+				return mcp.NewToolResultErrorFromErr("Invalid failures_path", err), nil
+			}
+			if err := writeImportFailures(failuresPath, columns, report.Failures); err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to write failure report", err), nil
+			}
+			report.FailuresPath = failuresPath
+		}
+
+		prettyJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		notes := make([]string, 0)
+		if report.NextRow > 0 {
+			notes = append(notes, fmt.Sprintf("Stopped before row %d of %d; call again with start_row %d to continue", report.NextRow, report.Rows, report.NextRow))
+		}
+		return withNotes(mcp.NewToolResultText(string(prettyJSON)), notes), nil
+	}
+}
+
+func CreateMessagesimportTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("import_sms_messages",
+		mcp.WithDescription("Recreate messages from CSV or NDJSON rows, e.g. scheduled messages exported from another provider. Each row is mapped onto a message, validated and sent through post_sms_messages in file order. Supports dry runs, resuming from a row and a report of failed rows."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("content", mcp.Description("Rows to import: CSV with a header row, or NDJSON with one object per line. Provide either content or path")),
+		mcp.WithString("path", mcp.Description("File on the server to import, e.g. an export_sms_messages file. In HTTP/HTTPS mode only a relative name in the server's exports directory is accepted")),
+		mcp.WithString("format", mcp.Description("csv or ndjson. Defaults to the file extension, or the content's shape")),
+		mcp.WithObject("mapping", mcp.Description("Source column to message field, e.g. {\"Recipient\": \"to\", \"Text\": \"body\"}. Columns already named after a field (to, from, body, subject, type, messaging_service_id, scheduled_at, webhook_url, reference) need no mapping; other columns are ignored")),
+		mcp.WithString("from", mcp.Description("Sender for rows without a from value")),
+		mcp.WithString("webhook_url", mcp.Description("Delivery webhook for rows without a webhook_url value")),
+		mcp.WithString("messaging_service_id", mcp.Description("Messaging service for rows without a messaging_service_id value")),
+		mcp.WithString("type", mcp.Description("Message type for rows without a type value: sms or mms")),
+		mcp.WithBoolean("only_scheduled", mcp.Description("Skip rows without a scheduled_at in the future, e.g. messages the previous provider already sent")),
+		mcp.WithBoolean("dry_run", mcp.Description("Validate every row without sending anything")),
+		mcp.WithNumber("start_row", mcp.Description("First data row to process (1 is the first row after the CSV header), e.g. next_row of an interrupted import")),
+		mcp.WithNumber("max_rows", mcp.Description("Process at most this many rows, then report next_row")),
+		mcp.WithString("failures_path", mcp.Description("Also write failed rows, with their error, as CSV to this file on the server. In HTTP/HTTPS mode only a relative name in the server's exports directory is accepted")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesimportHandler(cfg),
+	}
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMessagesimportReplay(t *testing.T) {
+	var sends atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sends.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status_code":201,"status":"Created","service":"twilio","resource":"messages","operation":"add","data":{"id":"msg_1"}}`))
+	}))
+	defer api.Close()
+
+	cfg := &config.APIConfig{BaseURL: api.URL, DataDir: t.TempDir(), IdempotencyTTL: time.Hour, ScheduleQueue: "off", Transport: "stdio"}
+	// The server records an opt-out while the import runs
+	list, err := suppression.Open(cfg.DataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.Add("acme", "+15550102", "replied STOP"); err != nil {
+		t.Fatal(err)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "import_sms_messages"
+	request.Params.Arguments = map[string]any{
+		"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app", "format": "ndjson",
+		"content": `{"id":"old_1","to":"+15550101","from":"+15550100","body":"One"}
+{"id":"old_2","to":"+15550102","from":"+15550100","body":"Two"}
+{"id":"old_3","to":"+15550103","from":"+15550100","body":"Three"}`,
+	}
+	for run := 1; run <= 2; run++ {
+		res, err := MessagesimportHandler(cfg)(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, _ := resultText(res)
+		var report ImportReport
+		if err := json.Unmarshal([]byte(text), &report); err != nil {
+			t.Fatalf("run %d: %s", run, text)
+		}
+		if report.Created != 2 || report.Failed != 1 || report.Failures[0].Row != 2 {
+			t.Fatalf("run %d report = %s, want 2 created and the opted-out row failed", run, text)
+		}
+	}
+	if got := sends.Load(); got != 2 {
+		t.Fatalf("API received %d requests, want 2: a replayed import must not send again", got)
+	}
+}
@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/media"
+	"github.com/sms-api/mcp-server/models"
+)
+
+// mediaContent is inline media before it is hosted
+type mediaContent struct {
+	data        []byte
+	contentType string
+}
+
+// resolveMedia validates the media items of a send and returns the URLs to attach. Items are URLs,
+// data: URLs, objects with a url or base64 data, or MCP embedded resources; inline content is
+// hosted by the server under PUBLIC_BASE_URL.
+func resolveMedia(ctx context.Context, cfg *config.APIConfig, value any) ([]string, error) {
+	items, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("media must be an array")
+	}
+	if len(items) > media.MaxItems {
+		return nil, fmt.Errorf("%d media items, at most %d are allowed", len(items), media.MaxItems)
+	}
+
+	urls := make([]string, 0, len(items))
+	inline := make(map[int]mediaContent)
+	var total int64
+	for i, item := range items {
+		link, content, err := parseMediaItem(item)
+		if err != nil {
+			return nil, fmt.Errorf("media item %d: %w", i+1, err)
+		}
+		if content != nil {
+			inline[i] = *content
+			total += int64(len(content.data))
+			urls = append(urls, "")
+			continue
+		}
+		// Media hosted by this server, e.g. of a queued send, was checked when it was stored
+		if cfg.PublicURL != "" && strings.HasPrefix(link, cfg.PublicURL+media.Path) {
+			urls = append(urls, link)
+			continue
+		}
+		contentType, size, err := media.Probe(ctx, link)
+		if err != nil {
+			return nil, fmt.Errorf("media item %d: %w", i+1, err)
+		}
+		if contentType == "" {
+			// Without a reported type the extension has to do; unknown extensions are left to the carrier
+			if byExt := contentTypeByExtension(link); byExt != "" {
+				if _, err := media.ContentType(byExt); err != nil {
+					return nil, fmt.Errorf("media item %d: %w", i+1, err)
+				}
+			}
+		}
+		total += size
+		urls = append(urls, link)
+	}
+	if total > cfg.MediaMaxBytes {
+		return nil, fmt.Errorf("media total %d bytes, at most %d are allowed (MEDIA_MAX_BYTES)", total, cfg.MediaMaxBytes)
+	}
+	if len(inline) == 0 {
+		return urls, nil
+	}
+
+	// Carriers fetch inline media from the server, which needs a public address and an HTTP listener
+	if cfg.PublicURL == "" || (cfg.Transport != "http" && cfg.Transport != "https") {
+		return nil, fmt.Errorf("inline media is hosted by the server, which requires HTTP mode and PUBLIC_BASE_URL; pass media URLs instead")
+	}
+	store, err := media.Open(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	store.Prune(cfg.MediaRetention)
+	for i, content := range inline {
+		name, err := store.Put(content.data, content.contentType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store media item %d: %w", i+1, err)
+		}
+		urls[i] = cfg.PublicURL + media.Path + name
+	}
+	return urls, nil
+}
+
+// withMediaURLs encodes a message with its media URLs. The Unify API has no field for them, so they
+// are sent as media_urls, which only some connectors read.
+func withMediaURLs(message models.Message, urls []string) ([]byte, error) {
+	body, err := json.Marshal(message)
+	if err != nil || len(urls) == 0 {
+		return body, err
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	fields["media_urls"] = urls
+	return json.Marshal(fields)
+}
+
+// parseMediaItem returns either the URL of a media item or its inline content
+func parseMediaItem(item any) (string, *mediaContent, error) {
+	switch item := item.(type) {
+	case string:
+		if strings.HasPrefix(item, "data:") {
+			content, err := parseDataURL(item)
+			return "", content, err
+		}
+		return mediaURL(item)
+	case map[string]any:
+		// MCP embedded resources wrap the content in a resource object
+		if resource, ok := item["resource"].(map[string]any); ok {
+			item = resource
+		}
+		contentType, _ := item["mimeType"].(string)
+		if val, ok := item["mime_type"].(string); ok {
+			contentType = val
+		}
+		if val, ok := item["url"].(string); ok {
+			return parseMediaItem(val)
+		}
+		if val, ok := item["blob"].(string); ok {
+			return inlineMedia(val, contentType)
+		}
+		if val, ok := item["data"].(string); ok {
+			return inlineMedia(val, contentType)
+		}
+		if val, ok := item["text"].(string); ok {
+			content, err := mediaBytes([]byte(val), contentType)
+			return "", content, err
+		}
+		if val, ok := item["uri"].(string); ok {
+			return parseMediaItem(val)
+		}
+		return "", nil, fmt.Errorf("expected url, data, blob or text")
+	default:
+		return "", nil, fmt.Errorf("expected a URL or an object")
+	}
+}
+
+// mediaURL checks that a link is an absolute http(s) URL carriers can fetch
+func mediaURL(link string) (string, *mediaContent, error) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", nil, fmt.Errorf("%q is not an absolute http(s) URL", link)
+	}
+	return link, nil, nil
+}
+
+// parseDataURL decodes a base64 data: URL
+func parseDataURL(link string) (*mediaContent, error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(link, "data:"), ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return nil, fmt.Errorf("data URLs must be base64 encoded")
+	}
+	_, content, err := inlineMedia(data, strings.TrimSuffix(header, ";base64"))
+	return content, err
+}
+
+// inlineMedia decodes base64 content
+func inlineMedia(data, contentType string) (string, *mediaContent, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+	content, err := mediaBytes(decoded, contentType)
+	return "", content, err
+}
+
+// mediaBytes checks the content type of inline content, detecting it when it is not given
+func mediaBytes(data []byte, contentType string) (*mediaContent, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty content")
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	contentType, err := media.ContentType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	return &mediaContent{data: data, contentType: contentType}, nil
+}
+
+// contentTypeByExtension guesses a content type from the extension of a URL path
+func contentTypeByExtension(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return map[string]string{
+		".jpg": "image/jpeg", ".jpeg": "image/jpeg", ".png": "image/png", ".gif": "image/gif", ".webp": "image/webp",
+		".bmp": "image/bmp", ".mp4": "video/mp4", ".3gp": "video/3gpp", ".mov": "video/quicktime", ".mp3": "audio/mpeg",
+		".amr": "audio/amr", ".wav": "audio/wav", ".vcf": "text/vcard", ".ics": "text/calendar", ".txt": "text/plain",
+		".pdf": "application/pdf", ".zip": "application/zip", ".exe": "application/octet-stream",
+	}[strings.ToLower(path.Ext(u.Path))]
+}
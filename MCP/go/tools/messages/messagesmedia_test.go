@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMessagesaddResolvesMediaAfterChecks(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		w.Write([]byte(`{"status_code":201,"status":"Created","data":{"id":"msg_1"}}`))
+	}))
+	defer api.Close()
+	cfg := &config.APIConfig{
+		BaseURL: api.URL, DataDir: t.TempDir(), IdempotencyTTL: time.Hour, ScheduleQueue: "off",
+		Transport: "http", PublicURL: "https://mcp.example.com", WebhookPath: "/webhooks/sms", MediaMaxBytes: 1 << 20,
+	}
+	suppressions, err := suppression.Open(cfg.DataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := suppressions.Add("acme", "+15550199", "STOP"); err != nil {
+		t.Fatal(err)
+	}
+	stored := func() int {
+		files, _ := os.ReadDir(filepath.Join(cfg.DataDir, "media"))
+		return len(files)
+	}
+	send := func(to, key string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app", "from": "+15550100", "to": to, "body": "Photo",
+			"idempotency_key": key, "media": []any{"data:image/png;base64,iVBORw0KGgo="},
+		}
+		res, err := MessagesaddHandler(cfg)(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, _ := resultText(res)
+		return text
+	}
+
+	if text := send("+15550199", "k1"); !strings.Contains(text, "opted out") || stored() != 0 {
+		t.Fatalf("suppressed send = %q with %d stored media files, want a rejection storing nothing", text, stored())
+	}
+	if text := send("+15550101", "k2"); !strings.Contains(text, "msg_1") || stored() != 1 {
+		t.Fatalf("send = %q with %d stored media files, want msg_1 and one file", text, stored())
+	}
+	os.RemoveAll(filepath.Join(cfg.DataDir, "media"))
+	if text := send("+15550101", "k2"); !strings.Contains(text, "msg_1") || stored() != 0 {
+		t.Fatalf("replay = %q with %d stored media files, want msg_1 storing nothing", text, stored())
+	}
+
+	// URLs on the server's own network are not probed
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("probed internal URL %s", r.URL)
+	}))
+	defer internal.Close()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app", "from": "+15550100", "to": "+15550101", "body": "Photo",
+		"media": []any{internal.URL + "/image.png"},
+	}
+	res, _ := MessagesaddHandler(cfg)(context.Background(), request)
+	if text, _ := resultText(res); !res.IsError || !strings.Contains(text, "not a public address") {
+		t.Fatalf("send with an internal media URL = %q, want a refused address", text)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/sms-api/mcp-server/config"
 	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/ratelimit"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -27,6 +29,19 @@ func MessagesoneHandler(cfg *config.APIConfig) func(ctx context.Context, request
 		if !ok {
 			return mcp.NewToolResultError("Invalid path parameter: id"), nil
 		}
+		// Serve from the cache while the consumer's message list is fresh
+		if c := useCache(cfg, args); c != nil {
+			scope := cacheScope(args)
+			if msg, found, err := c.Get(scope, id); err != nil {
+				log.Printf("Failed to read message cache: %v", err)
+			} else if freshness, err := c.Freshness(scope, cfg.CacheMaxAge); found && err == nil && !freshness.Stale {
+				prettyJSON, err := json.MarshalIndent(models.GetMessageResponse{Status: "OK", Status_code: 200, Data: *msg, Operation: "one", Resource: "messages"}, "", "  ")
+				if err != nil {
+					return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+				}
+				return withNotes(mcp.NewToolResultText(string(prettyJSON)), []string{freshnessNote(freshness)}), nil
+			}
+		}
 		queryParams := make([]string, 0)
 		if val, ok := args["raw"]; ok {
 			queryParams = append(queryParams, fmt.Sprintf("raw=%v", val))
@@ -59,6 +74,9 @@ func MessagesoneHandler(cfg *config.APIConfig) func(ctx context.Context, request
 			req.Header.Set("x-apideck-service-id", fmt.Sprintf("%v", val))
 		}
 
+		if err := ratelimit.Shared(cfg.RateLimit).Wait(ctx); err != nil {
+			return mcp.NewToolResultErrorFromErr("Request cancelled while waiting for the rate limiter", err), nil
+		}
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
@@ -79,6 +97,8 @@ func MessagesoneHandler(cfg *config.APIConfig) func(ctx context.Context, request
 			// Fallback to raw text if unmarshaling fails
 			return mcp.NewToolResultText(string(body)), nil
 		}
+		storeInCache(cfg, args, []models.Message{result.Data})
+		indexMessages(cfg, args, []models.Message{result.Data})
 
 		prettyJSON, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
@@ -97,6 +117,7 @@ func CreateMessagesoneTool(cfg *config.APIConfig) models.Tool {
 		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
 		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
 		mcp.WithBoolean("raw", mcp.Description("Include raw response. Mostly used for debugging purposes")),
+		mcp.WithString("source", mcp.Description("Where to read from when the message cache is enabled: cache (default, while the cached list is fresh) or api")),
 		mcp.WithString("fields", mcp.Description("The 'fields' parameter allows API users to specify the fields they want to include in the API response. If this parameter is not present, the API will return all available fields. If this parameter is present, only the fields specified in the comma-separated string will be included in the response. Nested properties can also be requested by using a dot notation. <br /><br />Example: `fields=name,email,addresses.city`<br /><br />In the example above, the response will only include the fields \"name\", \"email\" and \"addresses.city\". If any other fields are available, they will be excluded.")),
 	)
 
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/queue"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func MessagesqueuecancelHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		consumerId, _ := args["x-apideck-consumer-id"].(string)
+		if consumerId == "" {
+			return mcp.NewToolResultError("Missing required parameter: x-apideck-consumer-id"), nil
+		}
+		id, _ := args["id"].(string)
+		if id == "" {
+			return mcp.NewToolResultError("Missing required parameter: id"), nil
+		}
+		q, err := queue.Open(cfg.DataDir)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load scheduling queue", err), nil
+		}
+		job, err := q.Cancel(consumerId, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Cannot cancel", err), nil
+		}
+
+		prettyJSON, err := json.MarshalIndent(job, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return withNotes(mcp.NewToolResultText(string(prettyJSON)), []string{fmt.Sprintf("Canceled queued message %s; it will not be sent", id)}), nil
+	}
+}
+
+func CreateMessagesqueuecancelTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("cancel_queued_sms",
+		mcp.WithDescription("Cancel a message held in the server's scheduling queue before it is sent. Only pending jobs can be canceled; see list_queued_sms."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("id", mcp.Required(), mcp.Description("ID of the queued job, e.g. job_0123456789abcdef")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesqueuecancelHandler(cfg),
+	}
+}
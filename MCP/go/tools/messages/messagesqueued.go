@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/queue"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// QueuedResult is returned by post_sms_messages when the server holds the message in its queue
+type QueuedResult struct {
+	Queued bool      `json:"queued"`
+	Reason string    `json:"reason"`
+	Job    queue.Job `json:"job"`
+}
+
+// SentMessage is the outcome of a successful post_sms_messages call: the created message, or the
+// queue job that creates it when it is due
+type SentMessage struct {
+	MessageId string
+	JobId     string
+}
+
+// ParseSendResult reads the result of a successful post_sms_messages call. A queued message has no
+// message ID yet, only the ID of its job.
+func ParseSendResult(text string) (SentMessage, error) {
+	var queued QueuedResult
+	if err := json.Unmarshal([]byte(text), &queued); err == nil && queued.Queued {
+		return SentMessage{JobId: queued.Job.Id}, nil
+	}
+	var created models.CreateMessageResponse
+	if err := json.Unmarshal([]byte(text), &created); err != nil || created.Data.Id == "" {
+		return SentMessage{}, fmt.Errorf("unexpected response: %s", text)
+	}
+	return SentMessage{MessageId: created.Data.Id}, nil
+}
+
+// QueueList is the result of list_queued_sms
+type QueueList struct {
+	Jobs []queue.Job `json:"jobs"`
+}
+
+// queueSendTime returns the send time of a message and whether it is in the future
+func queueSendTime(scheduledAt string) (time.Time, bool) {
+	if scheduledAt == "" {
+		return time.Time{}, false
+	}
+	sendAt, err := time.Parse(time.RFC3339, scheduledAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return sendAt, sendAt.After(time.Now())
+}
+
+// enqueueMessage holds a message in the server's queue until sendAt and returns the formatted result.
+// The runner sends with the server's own API connection, since credentials are never stored with a
+// job, so a request using another connection from its headers cannot be queued.
+func enqueueMessage(cfg *config.APIConfig, args map[string]any, message models.Message, mediaURLs []string, callId string, sendAt time.Time, reason string) (string, error) {
+	if cfg.HeaderConnection {
+		return "", fmt.Errorf("the server's queue sends with the server's own API_BASE_URL and credentials, and this request uses other ones from its headers; credentials are not stored with queued messages")
+	}
+	q, err := queue.Open(cfg.DataDir)
+	if err != nil {
+		return "", err
+	}
+	message.Scheduled_at = ""
+	job := queue.Job{
+		ConsumerId: fmt.Sprintf("%v", args["x-apideck-consumer-id"]),
+		AppId:      fmt.Sprintf("%v", args["x-apideck-app-id"]),
+		Message:    message,
+		MediaURLs:  mediaURLs,
+		CallId:     callId,
+		SendAt:     sendAt,
+	}
+	job.ServiceId, _ = args["x-apideck-service-id"].(string)
+	if job, err = q.Add(job); err != nil {
+		return "", err
+	}
+	prettyJSON, err := json.MarshalIndent(QueuedResult{Queued: true, Reason: reason, Job: job}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(prettyJSON), nil
+}
+
+// QueueSender sends due jobs through post_sms_messages with the server's API connection
+func QueueSender(cfg *config.APIConfig) queue.Sender {
+	return func(ctx context.Context, job queue.Job) (string, bool, error) {
+		args := map[string]any{}
+		messageJSON, err := json.Marshal(job.Message)
+		if err != nil {
+			return "", false, err
+		}
+		if err := json.Unmarshal(messageJSON, &args); err != nil {
+			return "", false, err
+		}
+		args["x-apideck-consumer-id"] = job.ConsumerId
+		args["x-apideck-app-id"] = job.AppId
+		if job.ServiceId != "" {
+			args["x-apideck-service-id"] = job.ServiceId
+		}
+		if len(job.MediaURLs) > 0 {
+			media := make([]any, 0, len(job.MediaURLs))
+			for _, link := range job.MediaURLs {
+				media = append(media, link)
+			}
+			args["media"] = media
+		}
+		args["idempotency_key"] = queue.IdempotencyKey(job.Id)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "post_sms_messages"
+		request.Params.Arguments = args
+		if job.CallId != "" {
+			ctx = context.WithValue(ctx, webhookCallIdKey{}, job.CallId)
+		}
+		res, err := MessagesaddHandler(cfg)(ctx, request)
+		if err != nil {
+			return "", true, err
+		}
+		text, _ := resultText(res)
+		if res.IsError {
+			// Failures that prove the message was not sent may succeed later. After an ambiguous one
+			// the job is kept for review, since another attempt could send the message twice.
+			if strings.HasPrefix(text, ambiguousPrefix) {
+				return "", false, &queue.AmbiguousError{Err: fmt.Errorf("%s", text)}
+			}
+			status := apiErrorStatus(text)
+			retry := strings.HasPrefix(text, "Request failed") || status != 501 && failoverStatus(status)
+			return "", retry, fmt.Errorf("%s", text)
+		}
+		sent, err := ParseSendResult(text)
+		if err != nil {
+			return "", false, err
+		}
+		if sent.JobId != "" {
+			return "", false, fmt.Errorf("the send window deferred the message, it is queued again as %s", sent.JobId)
+		}
+		return sent.MessageId, false, nil
+	}
+}
+
+func MessagesqueuedHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		consumerId, _ := args["x-apideck-consumer-id"].(string)
+		if consumerId == "" {
+			return mcp.NewToolResultError("Missing required parameter: x-apideck-consumer-id"), nil
+		}
+		state, _ := args["state"].(string)
+		switch state {
+		case "", queue.StatePending, queue.StateSending, queue.StateSent, queue.StateFailed, queue.StateCanceled, queue.StateAmbiguous:
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid state %q, expected pending, sending, sent, failed, canceled or ambiguous", state)), nil
+		}
+		q, err := queue.Open(cfg.DataDir)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load scheduling queue", err), nil
+		}
+
+		jobs, err := q.List(consumerId, state)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load scheduling queue", err), nil
+		}
+		result := QueueList{Jobs: jobs}
+
+		prettyJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return mcp.NewToolResultText(string(prettyJSON)), nil
+	}
+}
+
+func CreateMessagesqueuedTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("list_queued_sms",
+		mcp.WithDescription("List messages held in the server's scheduling queue, soonest first. The server queues messages with a future scheduled_at when the connector cannot schedule them (or always, depending on SCHEDULE_QUEUE) and sends them when due. Jobs whose send may have reached the API without a confirmed result are marked ambiguous and not retried; check get_sms_messages for them. Finished jobs are listed for 7 days."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("state", mcp.Description("Only jobs in this state: pending, sending, sent, failed, canceled or ambiguous")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesqueuedHandler(cfg),
+	}
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/queue"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestQueueSender(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		closed        bool
+		wantRetry     bool
+		wantAmbiguous bool
+	}{
+		{name: "connection refused is retried", closed: true, wantRetry: true},
+		{name: "unavailable service is retried", status: 503, wantRetry: true},
+		{name: "rejected message fails", status: 400},
+		{name: "bad gateway is ambiguous", status: 502, wantAmbiguous: true},
+		{name: "dropped connection is ambiguous", status: dropConnection, wantAmbiguous: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == dropConnection {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprintf(w, `{"status_code":%d,"error":%q}`, tt.status, http.StatusText(tt.status))
+			}))
+			defer api.Close()
+			if tt.closed {
+				api.Close()
+			}
+
+			cfg := &config.APIConfig{BaseURL: api.URL, DataDir: t.TempDir(), IdempotencyTTL: time.Hour, ScheduleQueue: "off"}
+			job := queue.Job{Id: "job_1", ConsumerId: "acme", AppId: "app", Message: models.Message{From: "+15550100", To: "+15550101", Body: "Hello"}}
+			_, retry, err := QueueSender(cfg)(context.Background(), job)
+			var ambiguous *queue.AmbiguousError
+			if err == nil || retry != tt.wantRetry || errors.As(err, &ambiguous) != tt.wantAmbiguous {
+				t.Fatalf("QueueSender() = retry %v, %v; want retry %v, ambiguous %v", retry, err, tt.wantRetry, tt.wantAmbiguous)
+			}
+		})
+	}
+}
+
+func TestQueuedSendsReported(t *testing.T) {
+	later := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name    string
+		handler func(cfg *config.APIConfig) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args    map[string]any
+		check   func(text string, notes []string) error
+	}{
+		{
+			name:    "bulk",
+			handler: MessagesbulkHandler,
+			args:    map[string]any{"from": "+15550100", "body": "Hello", "scheduled_at": later, "recipients": []any{"+15550101"}},
+			check: func(text string, notes []string) error {
+				var summary BulkSummary
+				json.Unmarshal([]byte(text), &summary)
+				if result := summary.Results[0]; summary.Queued != 1 || summary.Sent != 0 || result.Status != "queued" || !strings.HasPrefix(result.JobId, "job_") || result.MessageId != "" {
+					return fmt.Errorf("summary = %s, want the recipient queued with its job", text)
+				}
+				return nil
+			},
+		},
+		{
+			name:    "import",
+			handler: MessagesimportHandler,
+			args:    map[string]any{"format": "ndjson", "content": `{"to":"+15550101","from":"+15550100","body":"Hello","scheduled_at":"` + later + `"}`},
+			check: func(text string, notes []string) error {
+				var report ImportReport
+				json.Unmarshal([]byte(text), &report)
+				if report.Queued != 1 || report.Created != 0 || len(report.Messages) != 1 || !strings.HasPrefix(report.Messages[0].JobId, "job_") {
+					return fmt.Errorf("report = %s, want the row queued with its job", text)
+				}
+				return nil
+			},
+		},
+		{
+			name:    "reply",
+			handler: MessagesreplyHandler,
+			args:    map[string]any{"id": "msg_in", "body": "Thanks", "scheduled_at": later},
+			check: func(text string, notes []string) error {
+				if len(notes) == 0 || !strings.Contains(notes[len(notes)-1], "Reply to message msg_in: queued as job_") {
+					return fmt.Errorf("notes = %q, want the reply queued", notes)
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("%s %s reached the API, want the message queued", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Write([]byte(`{"status_code":200,"status":"OK","data":{"id":"msg_in","direction":"inbound","from":"+15550101","to":"+15550100","body":"Hi"}}`))
+			}))
+			defer api.Close()
+
+			cfg := &config.APIConfig{BaseURL: api.URL, DataDir: t.TempDir(), IdempotencyTTL: time.Hour, ScheduleQueue: "always", Transport: "stdio"}
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app"}
+			for key, val := range tt.args {
+				request.Params.Arguments.(map[string]any)[key] = val
+			}
+			res, err := tt.handler(cfg)(context.Background(), request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, notes := resultText(res)
+			if res.IsError {
+				t.Fatalf("result = %q, want success", text)
+			}
+			if err := tt.check(text, notes); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestEnqueueMessageStoresNoCredentials(t *testing.T) {
+	tests := []struct {
+		name             string
+		headerConnection bool
+		wantErr          string
+	}{
+		{name: "server connection is queued"},
+		{name: "header connection is refused", headerConnection: true, wantErr: "credentials are not stored"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.APIConfig{BaseURL: "https://unify.example.com", APIKey: "secret-key", BearerToken: "secret-token", DataDir: t.TempDir(), HeaderConnection: tt.headerConnection}
+			args := map[string]any{"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app"}
+			message := models.Message{From: "+15550100", To: "+15550101", Body: "Hello"}
+			_, err := enqueueMessage(cfg, args, message, nil, "", time.Now().Add(time.Hour), "test")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("enqueueMessage() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(cfg.DataDir, "queue.json")
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); mode != 0o600 {
+				t.Fatalf("queue file mode = %v, want 0600", mode)
+			}
+			data, _ := os.ReadFile(path)
+			for _, secret := range []string{cfg.APIKey, cfg.BearerToken} {
+				if strings.Contains(string(data), secret) {
+					t.Fatalf("queue file contains a credential: %s", data)
+				}
+			}
+		})
+	}
+}
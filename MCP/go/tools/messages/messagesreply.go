@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func MessagesreplyHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		id, _ := args["id"].(string)
+		if id == "" {
+			return mcp.NewToolResultError("Missing required parameter: id"), nil
+		}
+		body, _ := args["body"].(string)
+		if strings.TrimSpace(body) == "" {
+			return mcp.NewToolResultError("Missing required parameter: body"), nil
+		}
+
+		fetchArgs := map[string]any{"id": id}
+		for _, key := range connectionArgs {
+			if val, ok := args[key]; ok {
+				fetchArgs[key] = val
+			}
+		}
+		fetchRequest := request
+		fetchRequest.Params.Name = "get_sms_messages_id"
+		fetchRequest.Params.Arguments = fetchArgs
+		fetchRequest.Params.Meta = nil
+		res, err := MessagesoneHandler(cfg)(ctx, fetchRequest)
+		if err != nil || res.IsError {
+			return res, err
+		}
+		text, _ := resultText(res)
+		var response models.GetMessageResponse
+		if err := json.Unmarshal([]byte(text), &response); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Unexpected response: %s", text)), nil
+		}
+		inbound := response.Data
+		if direction := strings.ToLower(inbound.Direction); direction != "inbound" {
+			return mcp.NewToolResultError(fmt.Sprintf("Message %s is not inbound (direction %q); only received messages can be replied to", id, inbound.Direction)), nil
+		}
+		if inbound.From == "" || inbound.To == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("Message %s has no from or to number to reply with", id)), nil
+		}
+
+		// The reply goes back from the number that received the message, through the same messaging service
+		sendArgs := map[string]any{"from": inbound.To, "to": inbound.From, "body": body}
+		if inbound.Messaging_service_id != "" {
+			sendArgs["messaging_service_id"] = inbound.Messaging_service_id
+		}
+		for key, val := range args {
+			switch key {
+			case "id", "body":
+			default:
+				sendArgs[key] = val
+			}
+		}
+		sendRequest := request
+		sendRequest.Params.Name = "post_sms_messages"
+		sendRequest.Params.Arguments = sendArgs
+		sendRequest.Params.Meta = nil
+		res, err = MessagesaddHandler(cfg)(ctx, sendRequest)
+		if err != nil || res.IsError {
+			return res, err
+		}
+		text, _ = resultText(res)
+		if sent, err := ParseSendResult(text); err == nil && sent.JobId != "" {
+			return withNotes(res, []string{fmt.Sprintf("Reply to message %s: queued as %s, to be sent from %s to %s", id, sent.JobId, inbound.To, inbound.From)}), nil
+		}
+		return withNotes(res, []string{fmt.Sprintf("Reply to message %s: sent from %s to %s", id, inbound.To, inbound.From)}), nil
+	}
+}
+
+func CreateMessagesreplyTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("reply_sms_message",
+		mcp.WithDescription("Reply to an inbound message. Loads the message with get_sms_messages_id, checks that it is inbound, and sends the body back to its sender from the number that received it, through the same messaging service. Opt-outs, the send window and idempotency apply as for post_sms_messages."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("id", mcp.Required(), mcp.Description("ID of the inbound message to reply to")),
+		mcp.WithString("body", mcp.Required(), mcp.Description("The reply text")),
+		mcp.WithString("reference", mcp.Description("A client reference for the reply")),
+		mcp.WithString("idempotency_key", mcp.Description("Key that makes retries safe: repeating a call with the same key returns the original response instead of sending again. Defaults to 'reference'.")),
+		mcp.WithString("scheduled_at", mcp.Description("Send the reply later, an RFC 3339 date-time")),
+		mcp.WithString("webhook_url", mcp.Description("Webhook to receive delivery notifications for the reply")),
+		mcp.WithString("recipient_timezone", mcp.Description("IANA time zone of the recipient used for the send window. Inferred from the country code of the number when omitted.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesreplyHandler(cfg),
+	}
+}
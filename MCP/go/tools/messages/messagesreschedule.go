@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/sendwindow"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func MessagesrescheduleHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		id, _ := args["id"].(string)
+		if id == "" {
+			return mcp.NewToolResultError("Missing required parameter: id"), nil
+		}
+		value, _ := args["scheduled_at"].(string)
+		scheduledAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid scheduled_at %q, expected an RFC 3339 date-time", value)), nil
+		}
+		if !scheduledAt.After(time.Now()) {
+			return mcp.NewToolResultError(fmt.Sprintf("scheduled_at %s is in the past", value)), nil
+		}
+
+		current, err := scheduledMessage(ctx, cfg, request, args, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Cannot reschedule", err), nil
+		}
+
+		// The new time must respect the recipient send window, like a new send would
+		notes := make([]string, 0)
+		if policy := cfg.SendWindow; policy != nil {
+			override, _ := args["recipient_timezone"].(string)
+			zones, err := sendwindow.RecipientZones(current.To, override)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Cannot apply send window", err), nil
+			}
+			decision, err := policy.Evaluate(scheduledAt, zones)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Send window violation", err), nil
+			}
+			if decision.Action == "scheduled" {
+				value = decision.ScheduledAt
+			}
+			decisionJSON, err := json.Marshal(decision)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+			}
+			notes = append(notes, fmt.Sprintf("Send window: %s", decisionJSON))
+		}
+
+		res, err := patchScheduledMessage(ctx, cfg, request, args, current, map[string]any{"scheduled_at": value})
+		if err != nil || res.IsError {
+			return res, err
+		}
+		notes = append(notes, fmt.Sprintf("Rescheduled message %s from %s to %s", id, current.Scheduled_at, value))
+		return withNotes(res, notes), nil
+	}
+}
+
+func CreateMessagesrescheduleTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("reschedule_sms",
+		mcp.WithDescription("Move a scheduled message to a new send time. The message must still be scheduled and the new time must be in the future; the send window applies as for new messages."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("id", mcp.Required(), mcp.Description("ID of the scheduled message")),
+		mcp.WithString("scheduled_at", mcp.Required(), mcp.Description("New send time, an RFC 3339 date-time in the future")),
+		mcp.WithString("recipient_timezone", mcp.Description("IANA time zone of the recipient for the send window, when it cannot be inferred from the number")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesrescheduleHandler(cfg),
+	}
+}
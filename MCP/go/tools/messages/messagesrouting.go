@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"sort"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/routing"
+	"github.com/sms-api/mcp-server/segments"
+)
+
+// Route decisions
+const (
+	RouteLeastCost  = "least_cost"  // The cheapest service with a rate for the destination
+	RouteOverride   = "override"    // The caller chose the service
+	RouteNoRate     = "no_rate"     // No service of the consumer has a rate for the destination; the default service is used
+	RouteNoServices = "no_services" // FAILOVER_SERVICES lists no services for the consumer; the default service is used
+	RouteFailover   = "failover"    // The chosen service failed and another one sent the message
+)
+
+// RouteDecision reports which service a send was routed to and what it is estimated to cost
+type RouteDecision struct {
+	Service       string          `json:"service"`
+	Decision      string          `json:"decision"`
+	EstimatedCost *float64        `json:"estimated_cost,omitempty"`
+	Currency      string          `json:"currency,omitempty"`
+	Units         int             `json:"units,omitempty"`
+	Alternatives  []routing.Route `json:"alternatives,omitempty"` // Other priced services, cheapest first
+
+	routes []routing.Route
+}
+
+// routeMessage picks the service for a send from the ROUTING_PRICES table. Only the consumer's services
+// from FAILOVER_SERVICES are candidates, since a priced service the consumer has not connected would
+// fail. The route argument or an explicit x-apideck-service-id overrides the choice. Without a table
+// the requested service is kept.
+func routeMessage(cfg *config.APIConfig, args map[string]any, msg models.Message) (string, *RouteDecision) {
+	requested, _ := args["x-apideck-service-id"].(string)
+	if override, _ := args["route"].(string); override != "" {
+		requested = override
+	}
+	if cfg.RoutingPrices == nil {
+		return requested, nil
+	}
+	connected := map[string]bool{}
+	for _, service := range consumerServices(cfg, args) {
+		connected[service] = true
+	}
+	routes := make([]routing.Route, 0)
+	for _, route := range cfg.RoutingPrices.Routes(msg.To, msg.TypeField, segments.Count(msg.Body).Segments) {
+		if connected[route.Service] {
+			routes = append(routes, route)
+		}
+	}
+	decision := &RouteDecision{routes: routes}
+	switch {
+	case requested != "":
+		decision.use(requested, RouteOverride)
+	case len(connected) == 0:
+		decision.Decision = RouteNoServices
+	case len(routes) == 0:
+		decision.Decision = RouteNoRate
+	default:
+		decision.use(routes[0].Service, RouteLeastCost)
+	}
+	return decision.Service, decision
+}
+
+// use records the service a message goes through, with its cost when it has a rate
+func (d *RouteDecision) use(service, decision string) {
+	d.Service, d.Decision = service, decision
+	d.EstimatedCost, d.Currency, d.Units = nil, "", 0
+	d.Alternatives = make([]routing.Route, 0, len(d.routes))
+	for _, route := range d.routes {
+		if route.Service != service {
+			d.Alternatives = append(d.Alternatives, route)
+			continue
+		}
+		cost := route.EstimatedCost
+		d.EstimatedCost, d.Currency, d.Units = &cost, route.Currency, route.Units
+	}
+}
+
+// backups orders the failover services after the first by estimated cost when routing picked the
+// cheapest service; services without a rate keep their configured order after the priced ones
+func (d *RouteDecision) backups(services []string) []string {
+	if d.Decision != RouteLeastCost || len(services) < 3 {
+		return services
+	}
+	rank := map[string]int{}
+	for i, route := range d.routes {
+		rank[route.Service] = i
+	}
+	ordered := append([]string{}, services...)
+	rest := ordered[1:]
+	sort.SliceStable(rest, func(i, j int) bool {
+		a, okA := rank[rest[i]]
+		b, okB := rank[rest[j]]
+		if okA && okB {
+			return a < b
+		}
+		return okA && !okB
+	})
+	return ordered
+}
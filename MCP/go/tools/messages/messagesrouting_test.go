@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/routing"
+)
+
+func TestRouteMessageConnectedServices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.yaml")
+	prices := "currency: USD\nrates:\n  - {service: vonage, prefix: \"1\", price: 0.001}\n  - {service: plivo, prefix: \"1\", price: 0.005}\n  - {service: twilio, prefix: \"1\", price: 0.008}\n"
+	if err := os.WriteFile(path, []byte(prices), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	table, err := routing.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		failover     map[string][]string
+		args         map[string]any
+		wantService  string
+		wantDecision string
+	}{
+		{
+			name:         "cheapest connected service",
+			failover:     map[string][]string{"acme": {"twilio", "plivo"}, "*": {"vonage"}},
+			wantService:  "plivo",
+			wantDecision: RouteLeastCost,
+		},
+		{
+			name:         "default list of services",
+			failover:     map[string][]string{"*": {"twilio", "vonage"}},
+			wantService:  "vonage",
+			wantDecision: RouteLeastCost,
+		},
+		{
+			name:         "no connected service has a rate",
+			failover:     map[string][]string{"acme": {"bandwidth"}},
+			wantDecision: RouteNoRate,
+		},
+		{
+			name:         "no list of services",
+			wantDecision: RouteNoServices,
+		},
+		{
+			name:         "override",
+			failover:     map[string][]string{"acme": {"twilio", "plivo"}},
+			args:         map[string]any{"route": "twilio"},
+			wantService:  "twilio",
+			wantDecision: RouteOverride,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.APIConfig{RoutingPrices: table, Failover: tt.failover}
+			args := map[string]any{"x-apideck-consumer-id": "acme"}
+			for key, val := range tt.args {
+				args[key] = val
+			}
+			service, decision := routeMessage(cfg, args, models.Message{To: "+15550101", Body: "Hello"})
+			if service != tt.wantService || decision.Decision != tt.wantDecision {
+				t.Fatalf("routeMessage() = %q, %q; want %q, %q", service, decision.Decision, tt.wantService, tt.wantDecision)
+			}
+			for _, route := range decision.Alternatives {
+				if route.Service == "vonage" && tt.failover["acme"] != nil {
+					t.Fatalf("alternatives = %+v, want only the consumer's services", decision.Alternatives)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Arguments identifying the consumer and connection, forwarded by the scheduled message tools
+var connectionArgs = []string{"x-apideck-consumer-id", "x-apideck-app-id", "x-apideck-service-id"}
+
+// scheduledMessage fetches a message from the API and checks that it is still waiting to be sent
+func scheduledMessage(ctx context.Context, cfg *config.APIConfig, request mcp.CallToolRequest, args map[string]any, id string) (*models.Message, error) {
+	fetchArgs := map[string]any{"id": id, "source": "api"}
+	for _, key := range connectionArgs {
+		if val, ok := args[key]; ok {
+			fetchArgs[key] = val
+		}
+	}
+	fetchRequest := request
+	fetchRequest.Params.Name = "get_sms_messages_id"
+	fetchRequest.Params.Arguments = fetchArgs
+	fetchRequest.Params.Meta = nil
+	res, err := MessagesoneHandler(cfg)(ctx, fetchRequest)
+	if err != nil {
+		return nil, err
+	}
+	text, _ := resultText(res)
+	if res.IsError {
+		return nil, fmt.Errorf("%s", text)
+	}
+	var response models.GetMessageResponse
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		return nil, fmt.Errorf("unexpected response: %s", text)
+	}
+	if status := strings.ToLower(response.Data.Status); status != "scheduled" {
+		return &response.Data, fmt.Errorf("message %s is %q, only scheduled messages can be changed", id, response.Data.Status)
+	}
+	return &response.Data, nil
+}
+
+// patchScheduledMessage updates a message through patch_sms_messages_id. The update sends every
+// message field, so the current values are sent along with the changes to leave them untouched.
+func patchScheduledMessage(ctx context.Context, cfg *config.APIConfig, request mcp.CallToolRequest, args map[string]any, current *models.Message, changes map[string]any) (*mcp.CallToolResult, error) {
+	patchArgs := map[string]any{"id": current.Id}
+	for key, val := range map[string]string{
+		"from": current.From, "to": current.To, "body": current.Body, "subject": current.Subject, "type": current.TypeField,
+		"messaging_service_id": current.Messaging_service_id, "scheduled_at": current.Scheduled_at,
+		"webhook_url": current.Webhook_url, "reference": current.Reference,
+	} {
+		if val != "" {
+			patchArgs[key] = val
+		}
+	}
+	for _, key := range connectionArgs {
+		if val, ok := args[key]; ok {
+			patchArgs[key] = val
+		}
+	}
+	for key, val := range changes {
+		patchArgs[key] = val
+	}
+	patchRequest := request
+	patchRequest.Params.Name = "patch_sms_messages_id"
+	patchRequest.Params.Arguments = patchArgs
+	patchRequest.Params.Meta = nil
+	return MessagesupdateHandler(cfg)(ctx, patchRequest)
+}
+
+func MessagesscheduledHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		listArgs := map[string]any{"status": "scheduled"}
+		for key, val := range args {
+			switch key {
+			case "status", "direction", "since", "until", "body_contains", "body_regex", "fields":
+			default:
+				listArgs[key] = val
+			}
+		}
+		listRequest := request
+		listRequest.Params.Name = "get_sms_messages"
+		listRequest.Params.Arguments = listArgs
+		res, err := MessagesallHandler(cfg)(ctx, listRequest)
+		if err != nil || res.IsError {
+			return res, err
+		}
+
+		// Order by send time, soonest first
+		text, notes := resultText(res)
+		var page models.GetMessagesResponse
+		if err := json.Unmarshal([]byte(text), &page); err != nil {
+			return res, nil
+		}
+		sort.SliceStable(page.Data, func(i, j int) bool {
+			a, errA := time.Parse(time.RFC3339, page.Data[i].Scheduled_at)
+			b, errB := time.Parse(time.RFC3339, page.Data[j].Scheduled_at)
+			if errA != nil || errB != nil {
+				return errA == nil
+			}
+			return a.Before(b)
+		})
+
+		prettyJSON, err := json.MarshalIndent(page, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return withNotes(mcp.NewToolResultText(string(prettyJSON)), notes), nil
+	}
+}
+
+func CreateMessagesscheduledTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("list_scheduled_sms",
+		mcp.WithDescription("List messages that are scheduled but not sent yet, soonest first. Pages through get_sms_messages keeping messages with status scheduled; use reschedule_sms or cancel_scheduled_sms to change them."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("to", mcp.Description("Only messages to this phone number")),
+		mcp.WithString("from", mcp.Description("Only messages from this phone number")),
+		mcp.WithNumber("limit", mcp.Description("Number of scheduled messages to return. Default 20")),
+		mcp.WithString("cursor", mcp.Description("Cursor to continue scanning from, see next_cursor in the Filter note")),
+		mcp.WithNumber("max_scan", mcp.Description("Maximum number of messages to read while looking for scheduled ones. Default 1000, maximum 10000")),
+		mcp.WithString("source", mcp.Description("Where to read from when the message cache is enabled: cache (default) or api")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesscheduledHandler(cfg),
+	}
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/search"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const maxSearchLimit = 100
+
+// searchIndex returns the full-text index, or nil when it is disabled or cannot be opened
+func searchIndex(cfg *config.APIConfig) *search.Index {
+	if !cfg.SearchIndex {
+		return nil
+	}
+	idx, err := search.Open(cfg.DataDir)
+	if err != nil {
+		log.Printf("Search index unavailable: %v", err)
+		return nil
+	}
+	return idx
+}
+
+// indexMessages adds messages read from the API to the search index. Partial records
+// requested with fields are skipped so they never replace a fully indexed message.
+func indexMessages(cfg *config.APIConfig, args map[string]any, messages []models.Message) {
+	if fields, _ := args["fields"].(string); fields != "" {
+		return
+	}
+	if idx := searchIndex(cfg); idx != nil {
+		consumerId, _ := args["x-apideck-consumer-id"].(string)
+		serviceId, _ := args["x-apideck-service-id"].(string)
+		if err := idx.Add(consumerId, serviceId, messages); err != nil {
+			log.Printf("Failed to index messages: %v", err)
+		}
+	}
+}
+
+// unindexMessage removes a deleted message from the search index
+func unindexMessage(cfg *config.APIConfig, args map[string]any, id string) {
+	if idx := searchIndex(cfg); idx != nil {
+		consumerId, _ := args["x-apideck-consumer-id"].(string)
+		serviceId, _ := args["x-apideck-service-id"].(string)
+		if err := idx.Remove(consumerId, serviceId, id); err != nil {
+			log.Printf("Failed to remove message from search index: %v", err)
+		}
+	}
+}
+
+func MessagessearchHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		idx := searchIndex(cfg)
+		if idx == nil {
+			return mcp.NewToolResultError("The search index is disabled, set SEARCH_INDEX=true"), nil
+		}
+
+		q := search.Query{Limit: 20}
+		q.ConsumerId, _ = args["x-apideck-consumer-id"].(string)
+		q.ServiceId, _ = args["x-apideck-service-id"].(string)
+		q.Text, _ = args["query"].(string)
+		q.From, _ = args["from"].(string)
+		q.To, _ = args["to"].(string)
+		q.Direction, _ = args["direction"].(string)
+		q.Status, _ = args["status"].(string)
+		q.Type, _ = args["type"].(string)
+		q.Sort, _ = args["sort"].(string)
+		if val, ok := args["limit"].(float64); ok && val >= 1 {
+			q.Limit = min(int(val), maxSearchLimit)
+		}
+		if val, ok := args["offset"].(float64); ok && val >= 0 {
+			q.Offset = int(val)
+		}
+		var err error
+		if q.Since, err = timeArg(args, "since"); err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid search", err), nil
+		}
+		if q.Until, err = timeArg(args, "until"); err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid search", err), nil
+		}
+
+		results, err := idx.Search(q)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Search failed", err), nil
+		}
+
+		prettyJSON, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		result := mcp.NewToolResultText(string(prettyJSON))
+		if next := q.Offset + len(results.Hits); uint64(next) < results.Total {
+			result = withNotes(result, []string{fmt.Sprintf("More results available, continue with offset %d", next)})
+		}
+		return result, nil
+	}
+}
+
+func CreateMessagessearchTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("search_sms_messages",
+		mcp.WithDescription("Full-text search over message bodies of a consumer. Only messages previously listed or fetched through this server are indexed; call sync_sms_messages or get_sms_messages to index more."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer whose messages to search")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Only search messages of this service")),
+		mcp.WithString("query", mcp.Description("Search terms. Supports \"exact phrases\", +required and -excluded terms, prefix* and fuzzy~ matching, and field:value for status, direction and type. Omit to match every message, e.g. with filters only")),
+		mcp.WithString("from", mcp.Description("Filter: sender phone number")),
+		mcp.WithString("to", mcp.Description("Filter: recipient phone number")),
+		mcp.WithString("direction", mcp.Description("Filter: message direction, e.g. inbound or outbound-api")),
+		mcp.WithString("status", mcp.Description("Filter: delivery status, e.g. delivered or failed")),
+		mcp.WithString("type", mcp.Description("Filter: sms or mms")),
+		mcp.WithString("since", mcp.Description("Filter: messages sent (or created) at or after this RFC 3339 date-time or YYYY-MM-DD date")),
+		mcp.WithString("until", mcp.Description("Filter: messages sent (or created) before this RFC 3339 date-time or YYYY-MM-DD date")),
+		mcp.WithString("sort", mcp.Description("Result order: relevance (default), newest or oldest")),
+		mcp.WithNumber("limit", mcp.Description("Number of results to return. Default 20, maximum 100")),
+		mcp.WithNumber("offset", mcp.Description("Number of results to skip, for paging")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagessearchHandler(cfg),
+	}
+}
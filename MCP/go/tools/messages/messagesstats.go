@@ -0,0 +1,278 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const defaultStatsMaxScan = 5000
+
+// Outbound statuses after which delivery has definitively succeeded or failed
+var finalDeliveryStatuses = map[string]bool{"delivered": true, "undelivered": true, "failed": true}
+
+// MessageStats aggregates the messages of one service, or of all services for the total
+type MessageStats struct {
+	Messages               int                `json:"messages"`
+	ByStatus               map[string]int     `json:"by_status"`
+	ByDirection            map[string]int     `json:"by_direction"`
+	ByType                 map[string]int     `json:"by_type"`
+	DeliveryRate           *float64           `json:"delivery_rate"`             // Delivered share of outbound messages with a final status
+	MedianSendDelaySeconds *float64           `json:"median_send_delay_seconds"` // Median time from created_at to sent_at
+	Units                  int                `json:"units"`
+	Spend                  map[string]float64 `json:"spend"` // Total price per currency
+
+	delivered int
+	final     int
+	delays    []float64
+}
+
+// StatsReport is the result of get_sms_stats
+type StatsReport struct {
+	Since          string                   `json:"since"`
+	Until          string                   `json:"until"`
+	Scanned        int                      `json:"scanned"`
+	ScanCapReached bool                     `json:"scan_cap_reached"`
+	Total          *MessageStats            `json:"total"`
+	Services       map[string]*MessageStats `json:"services"`
+	Failures       []ServiceFailure         `json:"failures"` // Services that could not be scanned; they are missing from the totals
+}
+
+func newMessageStats() *MessageStats {
+	return &MessageStats{ByStatus: map[string]int{}, ByDirection: map[string]int{}, ByType: map[string]int{}, Spend: map[string]float64{}}
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.ToLower(value)
+}
+
+// priceAmount reads a price's total_amount, falling back to per_unit times the number of units
+func priceAmount(msg models.Message) (string, float64, bool) {
+	currency, _ := msg.Price["currency"].(string)
+	parse := func(value any) (float64, bool) {
+		switch v := value.(type) {
+		case float64:
+			return v, true
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			return f, err == nil
+		}
+		return 0, false
+	}
+	if amount, ok := parse(msg.Price["total_amount"]); ok {
+		return currency, amount, true
+	}
+	if perUnit, ok := parse(msg.Price["per_unit"]); ok && msg.Number_of_units > 0 {
+		return currency, perUnit * float64(msg.Number_of_units), true
+	}
+	return "", 0, false
+}
+
+func (s *MessageStats) add(msg models.Message) {
+	s.Messages++
+	status := valueOrUnknown(msg.Status)
+	direction := valueOrUnknown(msg.Direction)
+	s.ByStatus[status]++
+	s.ByDirection[direction]++
+	s.ByType[valueOrUnknown(msg.TypeField)]++
+	if strings.HasPrefix(direction, "outbound") && finalDeliveryStatuses[status] {
+		s.final++
+		if status == "delivered" {
+			s.delivered++
+		}
+	}
+	created, errCreated := time.Parse(time.RFC3339, msg.Created_at)
+	sent, errSent := time.Parse(time.RFC3339, msg.Sent_at)
+	if errCreated == nil && errSent == nil && !sent.Before(created) {
+		s.delays = append(s.delays, sent.Sub(created).Seconds())
+	}
+	s.Units += msg.Number_of_units
+	if currency, amount, ok := priceAmount(msg); ok {
+		if currency == "" {
+			currency = "unknown"
+		}
+		s.Spend[strings.ToUpper(currency)] += amount
+	}
+}
+
+// finish computes the derived rates once every message was added
+func (s *MessageStats) finish() {
+	if s.final > 0 {
+		rate := math.Round(float64(s.delivered)/float64(s.final)*10000) / 10000
+		s.DeliveryRate = &rate
+	}
+	if n := len(s.delays); n > 0 {
+		sort.Float64s(s.delays)
+		median := s.delays[n/2]
+		if n%2 == 0 {
+			median = (s.delays[n/2-1] + s.delays[n/2]) / 2
+		}
+		s.MedianSendDelaySeconds = &median
+	}
+	for currency, amount := range s.Spend {
+		s.Spend[currency] = math.Round(amount*1e6) / 1e6
+	}
+}
+
+func MessagesstatsHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		until, err := timeArg(args, "until")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid time window", err), nil
+		}
+		if until.IsZero() {
+			until = time.Now()
+		}
+		since, err := timeArg(args, "since")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid time window", err), nil
+		}
+		if since.IsZero() {
+			since = until.Add(-24 * time.Hour)
+		}
+		if !since.Before(until) {
+			return mcp.NewToolResultError("since must be before until"), nil
+		}
+		maxScan := defaultStatsMaxScan
+		if val, ok := args["max_scan"].(float64); ok && val >= 1 {
+			maxScan = min(int(val), maxMaxScan)
+		}
+		window := &MessageFilter{Since: since, Until: until}
+
+		serviceIds := serviceIdsArg(args)
+		if len(serviceIds) == 0 {
+			serviceIds = []string{""}
+		}
+
+		report := StatsReport{
+			Since:    since.UTC().Format(time.RFC3339),
+			Until:    until.UTC().Format(time.RFC3339),
+			Total:    newMessageStats(),
+			Services: map[string]*MessageStats{},
+		}
+		notes := make([]string, 0)
+		// Services are scanned concurrently and share the max_scan budget
+		var mu sync.Mutex
+		reserved := 0
+		failures := fanOut(ctx, serviceIds, func(ctx context.Context, serviceId string) error {
+			listArgs := map[string]any{}
+			for _, key := range []string{"x-apideck-consumer-id", "x-apideck-app-id", "source"} {
+				if val, ok := args[key]; ok {
+					listArgs[key] = val
+				}
+			}
+			if serviceId != "" {
+				listArgs["x-apideck-service-id"] = serviceId
+			}
+			cursor := ""
+			for {
+				mu.Lock()
+				budget := min(200, maxScan-reserved)
+				if budget <= 0 {
+					if cursor != "" || report.Services[serviceId] == nil {
+						report.ScanCapReached = true
+					}
+					mu.Unlock()
+					return nil
+				}
+				reserved += budget
+				mu.Unlock()
+
+				listArgs["limit"] = budget
+				page, pageNotes, err := listMessagesPage(ctx, cfg, request, listArgs, cursor)
+				mu.Lock()
+				if err != nil {
+					reserved -= budget
+					mu.Unlock()
+					return err
+				}
+				reserved -= budget - len(page.Data)
+				if cursor == "" {
+					notes = append(notes, pageNotes...)
+				}
+				name := serviceId
+				if name == "" {
+					name = valueOrUnknown(page.Service)
+				}
+				stats, ok := report.Services[name]
+				if !ok {
+					stats = newMessageStats()
+					report.Services[name] = stats
+				}
+				report.Scanned += len(page.Data)
+				older := 0
+				for _, msg := range page.Data {
+					if window.Match(msg) {
+						stats.add(msg)
+						report.Total.add(msg)
+					} else if at, _ := messageTime(msg); !at.IsZero() && at.Before(since) {
+						older++
+					}
+				}
+				notifyProgress(ctx, request, float64(report.Scanned), float64(maxScan),
+					fmt.Sprintf("Scanned %d messages, %d in the window", report.Scanned, report.Total.Messages))
+				mu.Unlock()
+				// Messages are listed newest first, so a page entirely before the window ends the scan
+				if cursor = nextCursor(page); cursor == "" || len(page.Data) == 0 || older == len(page.Data) {
+					return nil
+				}
+			}
+		})
+		if len(failures) == len(serviceIds) {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list messages: %s", failures[0].Error)), nil
+		}
+		report.Failures = failures
+		report.Total.finish()
+		for _, stats := range report.Services {
+			stats.finish()
+		}
+
+		prettyJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		if len(failures) > 0 {
+			notes = append(notes, fmt.Sprintf("%d of %d services failed, see failures; the totals leave them out", len(failures), len(serviceIds)))
+		}
+		if report.ScanCapReached {
+			notes = append(notes, fmt.Sprintf("Stopped after scanning %d messages; the statistics may be incomplete, raise max_scan or narrow the window", report.Scanned))
+		}
+		return withNotes(mcp.NewToolResultText(string(prettyJSON)), notes), nil
+	}
+}
+
+func CreateMessagesstatsTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("get_sms_stats",
+		mcp.WithDescription("Aggregate delivery statistics over a time window: counts by status, direction and type, delivery rate, median send delay, units and spend, per service"),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("service_ids", mcp.Description("Comma-separated service IDs to aggregate separately (e.g. twilio,plivo), scanned concurrently. A failing service is reported in failures without failing the call. Defaults to the consumer's only service")),
+		mcp.WithString("since", mcp.Description("Start of the window, RFC 3339 date-time or YYYY-MM-DD date. Defaults to 24 hours before until")),
+		mcp.WithString("until", mcp.Description("End of the window (exclusive), RFC 3339 date-time or YYYY-MM-DD date. Defaults to now")),
+		mcp.WithNumber("max_scan", mcp.Description("Maximum number of messages to read across all services. Default 5000, maximum 10000")),
+		mcp.WithString("source", mcp.Description("Where to read from when the message cache is enabled: cache (default) or api")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagesstatsHandler(cfg),
+	}
+}
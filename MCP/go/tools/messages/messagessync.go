@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sms-api/mcp-server/cache"
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Cursor prefix of pages served from the local cache, which are offsets rather than API cursors
+const cacheCursorPrefix = "cache:"
+
+// Serializes syncs per scope so concurrent reads of a stale scope download it only once
+var syncLocks sync.Map
+
+// SyncReport is the result of sync_sms_messages
+type SyncReport struct {
+	Scope        string `json:"scope"`
+	Full         bool   `json:"full"`
+	PagesScanned int    `json:"pages_scanned"`
+	Scanned      int    `json:"scanned"`
+	Added        int    `json:"added"`
+	Updated      int    `json:"updated"`
+	Removed      int    `json:"removed"`
+	SyncedAt     string `json:"synced_at"`
+}
+
+// messageCache returns the local message cache, or nil when it is disabled or cannot be opened
+func messageCache(cfg *config.APIConfig) *cache.Cache {
+	if !cfg.MessageCache {
+		return nil
+	}
+	c, err := cache.Open(cfg.DataDir)
+	if err != nil {
+		log.Printf("Message cache unavailable: %v", err)
+		return nil
+	}
+	return c
+}
+
+// useCache reports whether a read should be served from the cache rather than the API
+func useCache(cfg *config.APIConfig, args map[string]any) *cache.Cache {
+	if source, _ := args["source"].(string); source == "api" {
+		return nil
+	}
+	if fields, _ := args["fields"].(string); fields != "" {
+		return nil // The cache holds whole records only
+	}
+	if raw, _ := args["raw"].(bool); raw {
+		return nil
+	}
+	return messageCache(cfg)
+}
+
+func cacheScope(args map[string]any) string {
+	consumerId, _ := args["x-apideck-consumer-id"].(string)
+	serviceId, _ := args["x-apideck-service-id"].(string)
+	return cache.Scope(consumerId, serviceId)
+}
+
+// invalidateCache drops a changed message (or, with an empty id, just marks the list stale) after a write
+func invalidateCache(cfg *config.APIConfig, args map[string]any, id string) {
+	if c := messageCache(cfg); c != nil {
+		if err := c.Invalidate(cacheScope(args), id); err != nil {
+			log.Printf("Failed to invalidate message cache: %v", err)
+		}
+	}
+}
+
+// storeInCache writes single messages read from the API through to the cache; lists are only written by syncs
+func storeInCache(cfg *config.APIConfig, args map[string]any, messages []models.Message) {
+	fields, _ := args["fields"].(string)
+	raw, _ := args["raw"].(bool)
+	if fields != "" || raw {
+		return
+	}
+	if c := messageCache(cfg); c != nil {
+		if _, _, err := c.Upsert(cacheScope(args), messages); err != nil {
+			log.Printf("Failed to update message cache: %v", err)
+		}
+	}
+}
+
+// syncMessages pages through the list endpoint into the cache. An incremental sync assumes the
+// newest messages come first and stops at the first page without new or changed messages; a full
+// sync reads every page and removes messages that no longer exist.
+func syncMessages(ctx context.Context, cfg *config.APIConfig, request mcp.CallToolRequest, args map[string]any, c *cache.Cache, full bool) (SyncReport, error) {
+	scope := cacheScope(args)
+	report := SyncReport{Scope: scope, Full: full}
+	pageArgs := map[string]any{"limit": 200, "source": "api"}
+	for _, key := range []string{"x-apideck-consumer-id", "x-apideck-app-id", "x-apideck-service-id"} {
+		if val, ok := args[key]; ok {
+			pageArgs[key] = val
+		}
+	}
+
+	started := time.Now()
+	seen := map[string]bool{}
+	cursor := ""
+	for {
+		page, _, err := listMessagesPage(ctx, cfg, request, pageArgs, cursor)
+		if err != nil {
+			return report, fmt.Errorf("page %d: %w", report.PagesScanned+1, err)
+		}
+		report.PagesScanned++
+		report.Scanned += len(page.Data)
+		added, updated, err := c.Upsert(scope, page.Data)
+		if err != nil {
+			return report, err
+		}
+		report.Added += added
+		report.Updated += updated
+		for _, msg := range page.Data {
+			seen[msg.Id] = true
+		}
+		notifyProgress(ctx, request, float64(report.Scanned), 0, fmt.Sprintf("Synced %d messages", report.Scanned))
+		if cursor = nextCursor(page); cursor == "" || len(page.Data) == 0 {
+			break
+		}
+		if !full && added == 0 && updated == 0 {
+			break
+		}
+	}
+	if full {
+		removed, err := c.Prune(scope, seen)
+		if err != nil {
+			return report, err
+		}
+		report.Removed = removed
+	}
+	if err := c.MarkSynced(scope, started); err != nil {
+		return report, err
+	}
+	report.SyncedAt = started.UTC().Format(time.RFC3339)
+	return report, nil
+}
+
+// freshCache syncs the scope first when it is stale and returns its freshness
+func freshCache(ctx context.Context, cfg *config.APIConfig, request mcp.CallToolRequest, args map[string]any, c *cache.Cache) (cache.Freshness, error) {
+	scope := cacheScope(args)
+	lock, _ := syncLocks.LoadOrStore(scope, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	freshness, err := c.Freshness(scope, cfg.CacheMaxAge)
+	if err != nil || !freshness.Stale {
+		return freshness, err
+	}
+	if _, err := syncMessages(ctx, cfg, request, args, c, freshness.SyncedAt == ""); err != nil {
+		return freshness, err
+	}
+	return c.Freshness(scope, cfg.CacheMaxAge)
+}
+
+// freshnessNote renders the freshness indicator attached to reads
+func freshnessNote(freshness cache.Freshness) string {
+	data, _ := json.Marshal(freshness)
+	return "Cache: " + string(data)
+}
+
+// listCachedMessages serves a get_sms_messages page from the cache, using offsets as cursors
+func listCachedMessages(ctx context.Context, cfg *config.APIConfig, request mcp.CallToolRequest, args map[string]any, c *cache.Cache) (*mcp.CallToolResult, error) {
+	freshness, err := freshCache(ctx, cfg, request, args, c)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to sync message cache", err), nil
+	}
+	offset := 0
+	if cursor, _ := args["cursor"].(string); cursor != "" {
+		if offset, err = strconv.Atoi(strings.TrimPrefix(cursor, cacheCursorPrefix)); err != nil || offset < 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid cache cursor %q", cursor)), nil
+		}
+	}
+	limit := 20
+	if val, ok := args["limit"]; ok {
+		if parsed, err := strconv.Atoi(fmt.Sprintf("%v", val)); err == nil && parsed >= 1 {
+			limit = min(parsed, 200)
+		}
+	}
+
+	messages, err := c.List(cacheScope(args))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to read message cache", err), nil
+	}
+	result := models.GetMessagesResponse{
+		Status_code: 200,
+		Status:      "OK",
+		Operation:   "all",
+		Resource:    "messages",
+		Data:        messages[min(offset, len(messages)):min(offset+limit, len(messages))],
+		Meta:        models.Meta{Cursors: map[string]interface{}{"next": nil}},
+	}
+	if offset > 0 {
+		result.Meta.Cursors["previous"] = cacheCursorPrefix + strconv.Itoa(max(offset-limit, 0))
+	}
+	if offset+limit < len(messages) {
+		result.Meta.Cursors["next"] = cacheCursorPrefix + strconv.Itoa(offset+limit)
+	}
+	result.Meta.Items_on_page = len(result.Data)
+
+	prettyJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+	}
+
+	return withNotes(mcp.NewToolResultText(string(prettyJSON)), []string{freshnessNote(freshness)}), nil
+}
+
+func MessagessyncHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		c := messageCache(cfg)
+		if c == nil {
+			return mcp.NewToolResultError("The message cache is disabled, set MESSAGE_CACHE=true"), nil
+		}
+		full, _ := args["full"].(bool)
+
+		scope := cacheScope(args)
+		lock, _ := syncLocks.LoadOrStore(scope, &sync.Mutex{})
+		lock.(*sync.Mutex).Lock()
+		report, err := syncMessages(ctx, cfg, request, args, c, full)
+		lock.(*sync.Mutex).Unlock()
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to sync messages", err), nil
+		}
+
+		prettyJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return mcp.NewToolResultText(string(prettyJSON)), nil
+	}
+}
+
+func CreateMessagessyncTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("sync_sms_messages",
+		mcp.WithDescription("Synchronize the local message cache of a consumer and service with the API"),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithBoolean("full", mcp.Description("Read every page and drop cached messages that no longer exist, instead of stopping at the first unchanged page")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessagessyncHandler(cfg),
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/sms-api/mcp-server/config"
 	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/ratelimit"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -74,6 +75,9 @@ func MessagesupdateHandler(cfg *config.APIConfig) func(ctx context.Context, requ
 			req.Header.Set("x-apideck-service-id", fmt.Sprintf("%v", val))
 		}
 
+		if err := ratelimit.Shared(cfg.RateLimit).Wait(ctx); err != nil {
+			return mcp.NewToolResultErrorFromErr("Request cancelled while waiting for the rate limiter", err), nil
+		}
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
@@ -88,6 +92,8 @@ func MessagesupdateHandler(cfg *config.APIConfig) func(ctx context.Context, requ
 		if resp.StatusCode >= 400 {
 			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
 		}
+		// Drop the stale cached copy
+		invalidateCache(cfg, args, id)
 		// Use properly typed response
 		var result models.UpdateMessageResponse
 		if err := json.Unmarshal(body, &result); err != nil {
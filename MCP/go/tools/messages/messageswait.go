@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/webhooks"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultWaitTimeout = 2 * time.Minute
+	maxWaitTimeout     = 15 * time.Minute
+	initialWaitPoll    = 2 * time.Second
+	maxWaitPoll        = 30 * time.Second
+)
+
+// Statuses after which a message no longer changes, so waiting for another status is pointless
+var terminalMessageStatuses = map[string]bool{
+	"delivered": true, "undelivered": true, "failed": true, "canceled": true, "received": true, "read": true,
+}
+
+// WaitResult is the result of wait_for_sms_status
+type WaitResult struct {
+	Reached       bool            `json:"reached"`   // The message reached one of the target statuses
+	TimedOut      bool            `json:"timed_out"` // The timeout passed first
+	Status        string          `json:"status"`
+	Source        string          `json:"source"` // "api" or "webhook", whichever reported the final status
+	WaitedSeconds float64         `json:"waited_seconds"`
+	Polls         int             `json:"polls"`
+	Message       *models.Message `json:"message,omitempty"`
+}
+
+func MessageswaitHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		id, _ := args["id"].(string)
+		if id == "" {
+			return mcp.NewToolResultError("Missing required parameter: id"), nil
+		}
+		targets := stringSet(args["statuses"])
+		if len(targets) == 0 {
+			targets = map[string]bool{"delivered": true, "undelivered": true, "failed": true}
+		}
+		timeout := defaultWaitTimeout
+		if val, ok := args["timeout_seconds"].(float64); ok && val > 0 {
+			timeout = min(time.Duration(val*float64(time.Second)), maxWaitTimeout)
+		}
+
+		// Webhook events report status changes as they happen; polling remains the fallback
+		var events *webhooks.Store
+		if cfg.WebhookSecret != "" {
+			var err error
+			if events, err = webhooks.Open(cfg.DataDir); err != nil {
+				log.Printf("Webhook events unavailable: %v", err)
+			}
+		}
+		consumerId, _ := args["x-apideck-consumer-id"].(string)
+		eventQuery := webhooks.Query{ConsumerId: consumerId, MessageId: id, Limit: 1}
+
+		fetchArgs := map[string]any{"id": id, "source": "api"}
+		for _, key := range []string{"x-apideck-consumer-id", "x-apideck-app-id", "x-apideck-service-id"} {
+			if val, ok := args[key]; ok {
+				fetchArgs[key] = val
+			}
+		}
+		fetch := MessagesoneHandler(cfg)
+		fetchRequest := request
+		fetchRequest.Params.Name = "get_sms_messages_id"
+		fetchRequest.Params.Arguments = fetchArgs
+		fetchRequest.Params.Meta = nil
+
+		start := time.Now()
+		deadline := start.Add(timeout)
+		result := WaitResult{}
+		interval := initialWaitPoll
+		for {
+			var changed <-chan struct{}
+			webhookStatus := ""
+			if events != nil {
+				changed = events.Changed()
+				matches, _ := events.List(eventQuery)
+				if len(matches) > 0 && targets[matches[0].Status] {
+					webhookStatus = matches[0].Status
+				}
+			}
+
+			// The API returns the full message, even when a webhook already reported the status
+			res, err := fetch(ctx, fetchRequest)
+			result.Polls++
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to fetch message", err), nil
+			}
+			text, _ := resultText(res)
+			if res.IsError {
+				return mcp.NewToolResultError(text), nil
+			}
+			var response models.GetMessageResponse
+			if err := json.Unmarshal([]byte(text), &response); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Unexpected response: %s", text)), nil
+			}
+			result.Message = &response.Data
+			// The API may lag behind a webhook that already reported a target status
+			switch status := strings.ToLower(response.Data.Status); {
+			case targets[status] || webhookStatus == "":
+				result.Status, result.Source = status, "api"
+			default:
+				result.Status, result.Source = webhookStatus, "webhook"
+			}
+			if targets[result.Status] {
+				result.Reached = true
+				break
+			}
+			if terminalMessageStatuses[result.Status] {
+				break
+			}
+
+			elapsed := time.Since(start)
+			notifyProgress(ctx, request, elapsed.Seconds(), timeout.Seconds(),
+				fmt.Sprintf("Message %s is %q after %ds", id, result.Status, int(elapsed.Seconds())))
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				result.TimedOut = true
+				break
+			}
+			// Poll again when the interval passes or a webhook reports a new status for this message
+			timer := time.NewTimer(min(interval, remaining))
+		wait:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return mcp.NewToolResultErrorFromErr("Wait cancelled", ctx.Err()), nil
+				case <-changed:
+					changed = events.Changed()
+					if matches, _ := events.List(eventQuery); len(matches) > 0 && matches[0].Status != result.Status {
+						timer.Stop()
+						break wait
+					}
+				case <-timer.C:
+					interval = min(interval*2, maxWaitPoll)
+					break wait
+				}
+			}
+		}
+		result.WaitedSeconds = time.Since(start).Round(time.Millisecond).Seconds()
+
+		prettyJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		notes := make([]string, 0)
+		switch {
+		case result.TimedOut:
+			notes = append(notes, fmt.Sprintf("Timed out after %s with status %q; call again to keep waiting", timeout, result.Status))
+		case !result.Reached:
+			notes = append(notes, fmt.Sprintf("Message reached the final status %q, which is not one of the target statuses", result.Status))
+		}
+		return withNotes(mcp.NewToolResultText(string(prettyJSON)), notes), nil
+	}
+}
+
+func CreateMessageswaitTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("wait_for_sms_status",
+		mcp.WithDescription("Wait until a message reaches one of the target statuses, e.g. after sending it. Polls the message with backoff, or reacts to webhook events when the webhook receiver is enabled, and reports progress. Returns the message, or its last status when it times out or ends in another final status."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("id", mcp.Required(), mcp.Description("ID of the message to wait for")),
+		mcp.WithString("statuses", mcp.Description("Comma-separated statuses to wait for. Default delivered,undelivered,failed")),
+		mcp.WithNumber("timeout_seconds", mcp.Description("How long to wait. Default 120, maximum 900")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    MessageswaitHandler(cfg),
+	}
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func SuppressionaddHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		consumerId, ok := args["x-apideck-consumer-id"].(string)
+		if !ok || consumerId == "" {
+			return mcp.NewToolResultError("Missing required parameter: x-apideck-consumer-id"), nil
+		}
+		number, ok := args["number"].(string)
+		if !ok || suppression.NormalizeNumber(number) == "" {
+			return mcp.NewToolResultError("Missing required parameter: number"), nil
+		}
+		reason, _ := args["reason"].(string)
+
+		list, err := suppression.Open(cfg.DataDir)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load suppression list", err), nil
+		}
+		entry, err := list.Add(consumerId, number, reason)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to save suppression list", err), nil
+		}
+
+		prettyJSON, err := json.MarshalIndent(entry, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return mcp.NewToolResultText(string(prettyJSON)), nil
+	}
+}
+
+func CreateSuppressionaddTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("add_sms_suppression",
+		mcp.WithDescription("Add a phone number to the consumer's opt-out (STOP) suppression list. post_sms_messages refuses to send to suppressed numbers."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("number", mcp.Required(), mcp.Description("Phone number that opted out, e.g. +15017122662")),
+		mcp.WithString("reason", mcp.Description("Why the number is suppressed, e.g. \"Requested by phone\"")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    SuppressionaddHandler(cfg),
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func SuppressionallHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		consumerId, ok := args["x-apideck-consumer-id"].(string)
+		if !ok || consumerId == "" {
+			return mcp.NewToolResultError("Missing required parameter: x-apideck-consumer-id"), nil
+		}
+
+		list, err := suppression.Open(cfg.DataDir)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load suppression list", err), nil
+		}
+
+		entries, err := list.Entries(consumerId)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load suppression list", err), nil
+		}
+
+		prettyJSON, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return mcp.NewToolResultText(string(prettyJSON)), nil
+	}
+}
+
+func CreateSuppressionallTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("list_sms_suppressions",
+		mcp.WithDescription("List the consumer's suppressed (opted-out) phone numbers. The list is also updated automatically from STOP/START replies seen by get_sms_messages."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    SuppressionallHandler(cfg),
+	}
+}
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/suppression"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func SuppressiondeleteHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		consumerId, ok := args["x-apideck-consumer-id"].(string)
+		if !ok || consumerId == "" {
+			return mcp.NewToolResultError("Missing required parameter: x-apideck-consumer-id"), nil
+		}
+		number, ok := args["number"].(string)
+		if !ok || suppression.NormalizeNumber(number) == "" {
+			return mcp.NewToolResultError("Missing required parameter: number"), nil
+		}
+
+		list, err := suppression.Open(cfg.DataDir)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load suppression list", err), nil
+		}
+		removed, err := list.Remove(consumerId, number)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to save suppression list", err), nil
+		}
+		if !removed {
+			return mcp.NewToolResultText(fmt.Sprintf("%s was not on the suppression list", suppression.NormalizeNumber(number))), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("%s removed from the suppression list", suppression.NormalizeNumber(number))), nil
+	}
+}
+
+func CreateSuppressiondeleteTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("remove_sms_suppression",
+		mcp.WithDescription("Remove a phone number from the consumer's suppression list. Only use this when the recipient has opted back in (e.g. replied START)."),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("number", mcp.Required(), mcp.Description("Phone number to remove, e.g. +15017122662")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    SuppressiondeleteHandler(cfg),
+	}
+}
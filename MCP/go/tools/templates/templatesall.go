@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/templates"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TemplatesallHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		loaded, err := templates.Load(cfg.TemplatesDir)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to load templates", err), nil
+		}
+		result := make([]*templates.Template, 0, len(loaded))
+		for _, tmpl := range loaded {
+			result = append(result, tmpl)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+		prettyJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return mcp.NewToolResultText(string(prettyJSON)), nil
+	}
+}
+
+func CreateTemplatesallTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("list_sms_templates",
+		mcp.WithDescription("List the named message templates with their variables and locales"),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    TemplatesallHandler(cfg),
+	}
+}
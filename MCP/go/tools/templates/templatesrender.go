@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	"github.com/sms-api/mcp-server/segments"
+	"github.com/sms-api/mcp-server/templates"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// RenderedTemplate is the preview returned by render_sms_template
+type RenderedTemplate struct {
+	Template string        `json:"template"`
+	Locale   string        `json:"locale"`
+	Body     string        `json:"body"`
+	Segments segments.Info `json:"segments"`
+}
+
+// renderTemplate renders the template named in args with its locale and variables arguments
+func renderTemplate(cfg *config.APIConfig, args map[string]any) (*RenderedTemplate, error) {
+	name, ok := args["template"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("missing required parameter: template")
+	}
+	loaded, err := templates.Load(cfg.TemplatesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+	tmpl, ok := loaded[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %q, use list_sms_templates to see the available templates", name)
+	}
+	locale, _ := args["locale"].(string)
+	vars := map[string]any{}
+	if val, ok := args["variables"]; ok && val != nil {
+		if vars, ok = val.(map[string]any); !ok {
+			return nil, fmt.Errorf("invalid parameter: variables must be an object")
+		}
+	}
+	body, resolved, err := tmpl.Render(locale, vars)
+	if err != nil {
+		return nil, err
+	}
+	return &RenderedTemplate{Template: name, Locale: resolved, Body: body, Segments: segments.Count(body)}, nil
+}
+
+func TemplatesrenderHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		rendered, err := renderTemplate(cfg, args)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to render template", err), nil
+		}
+
+		prettyJSON, err := json.MarshalIndent(rendered, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+
+		return mcp.NewToolResultText(string(prettyJSON)), nil
+	}
+}
+
+func CreateTemplatesrenderTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("render_sms_template",
+		mcp.WithDescription("Preview a named message template: renders the body for a locale and reports its encoding and SMS segment count"),
+		mcp.WithString("template", mcp.Required(), mcp.Description("Name of the template, see list_sms_templates")),
+		mcp.WithString("locale", mcp.Description("Locale of the variant to render, e.g. fr or pt-BR. Falls back to the base language, then the template's default locale.")),
+		mcp.WithObject("variables", mcp.Description("Values for the template's variables, keyed by variable name")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    TemplatesrenderHandler(cfg),
+	}
+}
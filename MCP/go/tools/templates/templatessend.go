@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/sms-api/mcp-server/models"
+	tools_messages "github.com/sms-api/mcp-server/tools/messages"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TemplatessendHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		rendered, err := renderTemplate(cfg, args)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to render template", err), nil
+		}
+
+		// Send through post_sms_messages so opt-outs and the send window still apply
+		sendArgs := make(map[string]any, len(args))
+		for key, val := range args {
+			switch key {
+			case "template", "locale", "variables":
+			default:
+				sendArgs[key] = val
+			}
+		}
+		sendArgs["body"] = rendered.Body
+		sendRequest := request
+		sendRequest.Params.Name = "post_sms_messages"
+		sendRequest.Params.Arguments = sendArgs
+		result, err := tools_messages.MessagesaddHandler(cfg)(ctx, sendRequest)
+		if err != nil || result.IsError {
+			return result, err
+		}
+
+		renderedJSON, err := json.Marshal(rendered)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		}
+		result.Content = append(result.Content, mcp.NewTextContent("Rendered template: "+string(renderedJSON)))
+		if text, ok := result.Content[0].(mcp.TextContent); ok {
+			if sent, err := tools_messages.ParseSendResult(text.Text); err == nil && sent.JobId != "" {
+				result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf("Queued: the rendered body is held as %s and sent when due; later template changes do not apply to it", sent.JobId)))
+			}
+		}
+		return result, nil
+	}
+}
+
+func CreateTemplatessendTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("send_sms_template",
+		mcp.WithDescription("Render a named message template and send it with post_sms_messages in one call. Opt-outs, the send window, idempotency, routing and failover apply as for post_sms_messages."),
+		mcp.WithString("template", mcp.Required(), mcp.Description("Name of the template, see list_sms_templates")),
+		mcp.WithString("locale", mcp.Description("Locale of the variant to render, e.g. fr or pt-BR. Falls back to the base language, then the template's default locale.")),
+		mcp.WithObject("variables", mcp.Description("Values for the template's variables, keyed by variable name")),
+		mcp.WithBoolean("raw", mcp.Description("Include raw response. Mostly used for debugging purposes")),
+		mcp.WithString("x-apideck-consumer-id", mcp.Required(), mcp.Description("ID of the consumer which you want to get or push data from")),
+		mcp.WithString("x-apideck-app-id", mcp.Required(), mcp.Description("The ID of your Unify application")),
+		mcp.WithString("x-apideck-service-id", mcp.Description("Provide the service id you want to call (e.g., pipedrive). Only needed when a consumer has activated multiple integrations for a Unified API.")),
+		mcp.WithString("from", mcp.Required(), mcp.Description("Input parameter: The phone number that initiated the message.")),
+		mcp.WithString("to", mcp.Required(), mcp.Description("Input parameter: The phone number that received the message.")),
+		mcp.WithString("subject", mcp.Description("")),
+		mcp.WithString("type", mcp.Description("Input parameter: Set to sms for SMS messages and mms for MMS messages.")),
+		mcp.WithString("messaging_service_id", mcp.Description("Input parameter: The ID of the Messaging Service used with the message. In case of Plivo this links to the Powerpack ID.")),
+		mcp.WithString("scheduled_at", mcp.Description("Input parameter: The scheduled date and time of the message.")),
+		mcp.WithString("reference", mcp.Description("Input parameter: A client reference.")),
+		mcp.WithString("webhook_url", mcp.Description("Input parameter: Define a webhook to receive delivery notifications.")),
+		mcp.WithString("recipient_timezone", mcp.Description("IANA time zone of the recipient (e.g. Europe/Paris) used for the send window. Inferred from the country code of 'to' when omitted.")),
+		mcp.WithArray("media", mcp.Description("Media to attach, which makes the message an MMS, as for post_sms_messages. The URLs are sent in media_urls, a connector-specific field outside the Unify API.")),
+		mcp.WithString("idempotency_key", mcp.Description("Key that makes retries safe: repeating a call with the same key returns the original response instead of sending again. Defaults to 'reference'.")),
+		mcp.WithString("route", mcp.Description("Service ID to send through, overriding least-cost routing from ROUTING_PRICES. An explicit x-apideck-service-id also overrides it.")),
+		mcp.WithBoolean("failover", mcp.Description("Try the consumer's other services from FAILOVER_SERVICES when the service fails without sending. Default true; false sends through x-apideck-service-id only.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    TemplatessendHandler(cfg),
+	}
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sms-api/mcp-server/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// writeTemplate stores a welcome template with a required name variable in a new directory
+func writeTemplate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "welcome.yaml"), []byte("variables:\n  name: {type: string, required: true}\nlocales:\n  en: Welcome {{.name}}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestTemplatessendSendOptions(t *testing.T) {
+	var sends atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sends.Add(1)
+		if service := r.Header.Get("x-apideck-service-id"); service != "plivo" {
+			t.Errorf("message sent through %q, want the route plivo", service)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status_code":201,"status":"Created","service":"plivo","resource":"messages","operation":"add","data":{"id":"msg_1"}}`))
+	}))
+	defer api.Close()
+
+	cfg := &config.APIConfig{BaseURL: api.URL, DataDir: t.TempDir(), TemplatesDir: writeTemplate(t), IdempotencyTTL: time.Hour, ScheduleQueue: "off"}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app", "template": "welcome", "variables": map[string]any{"name": "Ada"},
+		"from": "+15550100", "to": "+15550101", "idempotency_key": "welcome-ada", "route": "plivo", "failover": false,
+	}
+	for call := 1; call <= 2; call++ {
+		res, err := TemplatessendHandler(cfg)(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := res.Content[0].(mcp.TextContent).Text; res.IsError || !strings.Contains(text, `"id": "msg_1"`) {
+			t.Fatalf("call %d = %q, want the created message", call, text)
+		}
+	}
+	if got := sends.Load(); got != 1 {
+		t.Fatalf("API received %d requests, want 1: the idempotency key must replay the second call", got)
+	}
+	for _, name := range []string{"idempotency_key", "route", "failover", "media"} {
+		if _, ok := CreateTemplatessendTool(cfg).Definition.InputSchema.Properties[name]; !ok {
+			t.Errorf("send_sms_template does not declare %s", name)
+		}
+	}
+}
+
+func TestTemplatessendQueued(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("%s %s reached the API, want the message queued", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer api.Close()
+
+	cfg := &config.APIConfig{BaseURL: api.URL, DataDir: t.TempDir(), TemplatesDir: writeTemplate(t), IdempotencyTTL: time.Hour, ScheduleQueue: "always"}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"x-apideck-consumer-id": "acme", "x-apideck-app-id": "app", "template": "welcome", "variables": map[string]any{"name": "Ada"},
+		"from": "+15550100", "to": "+15550101", "scheduled_at": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	}
+	res, err := TemplatessendHandler(cfg)(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := ""
+	for _, content := range res.Content {
+		text += content.(mcp.TextContent).Text + "\n"
+	}
+	if res.IsError || !strings.Contains(text, `"queued": true`) || !strings.Contains(text, "Queued: the rendered body is held as job_") {
+		t.Fatalf("result = %q, want the message queued with a note naming its job", text)
+	}
+}
@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sms-api/mcp-server/store"
+)
+
+// Event types
+const (
+	EventDeliveryStatus = "delivery_status"
+	EventInboundMessage = "inbound_message"
+)
+
+// maxEvents bounds the event log; the oldest events are dropped first
+const maxEvents = 5000
+
+// Event is a webhook callback received from the SMS provider
+type Event struct {
+	Id         string          `json:"id"` // Hash of the payload, so redelivered callbacks are stored once
+	Type       string          `json:"type"`
+	EventType  string          `json:"event_type,omitempty"` // The provider's own event name, when it sends one
+	ReceivedAt time.Time       `json:"received_at"`
+	OccurredAt string          `json:"occurred_at,omitempty"`
+	ConsumerId string          `json:"consumer_id,omitempty"`
+	ServiceId  string          `json:"service_id,omitempty"`
+	MessageId  string          `json:"message_id,omitempty"`
+	Status     string          `json:"status,omitempty"`
+	From       string          `json:"from,omitempty"`
+	To         string          `json:"to,omitempty"`
+	Body       string          `json:"body,omitempty"`
+	Reference  string          `json:"reference,omitempty"` // Client reference of the message
+	CallId     string          `json:"call_id,omitempty"`   // post_sms_messages call that issued the callback URL
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// Query selects events; empty fields match every event
+type Query struct {
+	ConsumerId string
+	MessageId  string
+	Type       string
+	Status     string
+	Reference  string
+	CallId     string
+	Since      time.Time
+	Limit      int
+}
+
+// Store keeps received events under the data directory
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	events  []Event       // Oldest first
+	changed chan struct{} // Closed and replaced whenever an event is added
+}
+
+var (
+	openMu sync.Mutex
+	opened = map[string]*Store{}
+)
+
+// Open returns the event store under dataDir, loading it on first use
+func Open(dataDir string) (*Store, error) {
+	path := filepath.Join(dataDir, "events.json")
+	openMu.Lock()
+	defer openMu.Unlock()
+	if s, ok := opened[path]; ok {
+		return s, nil
+	}
+	s := &Store{path: path, events: make([]Event, 0), changed: make(chan struct{})}
+	if err := store.LoadJSON(path, &s.events); err != nil {
+		return nil, err
+	}
+	opened[path] = s
+	return s, nil
+}
+
+// Add stores an event and drops events older than retention. It reports false when the
+// event was already stored, e.g. because the provider retried the callback.
+func (s *Store) Add(event Event, retention time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.events {
+		if existing.Id == event.Id {
+			return false, nil
+		}
+	}
+	s.events = append(s.events, event)
+	close(s.changed)
+	s.changed = make(chan struct{})
+	if retention > 0 {
+		cutoff := time.Now().Add(-retention)
+		keep := sort.Search(len(s.events), func(i int) bool { return s.events[i].ReceivedAt.After(cutoff) })
+		s.events = s.events[keep:]
+	}
+	if len(s.events) > maxEvents {
+		s.events = s.events[len(s.events)-maxEvents:]
+	}
+	return true, store.SaveJSON(s.path, s.events)
+}
+
+// Changed returns a channel that is closed when the next event is added
+func (s *Store) Changed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// List returns the events matching q, newest first, and the number of matches before the limit
+func (s *Store) List(q Query) ([]Event, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matches := make([]Event, 0)
+	for i := len(s.events) - 1; i >= 0; i-- {
+		event := s.events[i]
+		if (q.ConsumerId != "" && event.ConsumerId != q.ConsumerId) ||
+			(q.MessageId != "" && event.MessageId != q.MessageId) ||
+			(q.Type != "" && event.Type != q.Type) ||
+			(q.Status != "" && event.Status != q.Status) ||
+			(q.Reference != "" && event.Reference != q.Reference) ||
+			(q.CallId != "" && event.CallId != q.CallId) ||
+			(!q.Since.IsZero() && event.ReceivedAt.Before(q.Since)) {
+			continue
+		}
+		matches = append(matches, event)
+	}
+	total := len(matches)
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+	return matches, total
+}
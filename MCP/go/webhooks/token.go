@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenClaims identify the tool call a callback URL was issued for
+type TokenClaims struct {
+	ConsumerId string `json:"c"`
+	ServiceId  string `json:"s,omitempty"`
+	Reference  string `json:"r,omitempty"` // Client reference of the message
+	CallId     string `json:"n"`           // Random ID of the post_sms_messages call
+	IssuedAt   int64  `json:"t"`
+	SendAt     int64  `json:"a,omitempty"` // Scheduled send time, when it is later than IssuedAt
+}
+
+// NewCallId returns a random ID for a tool call
+func NewCallId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewToken encodes claims as "<payload>.<signature>", both base64url, signed with HMAC-SHA256 under secret
+func NewToken(secret string, claims TokenClaims) (string, error) {
+	if claims.IssuedAt == 0 {
+		claims.IssuedAt = time.Now().Unix()
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ParseToken verifies a token issued by NewToken and returns its claims. Tokens expire maxAge after
+// they were issued, or after the scheduled send time of their message.
+func ParseToken(secret, token string, maxAge time.Duration) (TokenClaims, error) {
+	var claims TokenClaims
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return claims, fmt.Errorf("malformed token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return claims, fmt.Errorf("malformed token signature")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return claims, fmt.Errorf("invalid token signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return claims, fmt.Errorf("malformed token payload")
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, fmt.Errorf("malformed token payload: %w", err)
+	}
+	if claims.ConsumerId == "" {
+		return claims, fmt.Errorf("token names no consumer")
+	}
+	if expires := time.Unix(max(claims.IssuedAt, claims.SendAt), 0).Add(maxAge); time.Now().After(expires) {
+		return claims, fmt.Errorf("token expired at %s", expires.UTC().Format(time.RFC3339))
+	}
+	return claims, nil
+}
@@ -0,0 +1,41 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTokenExpiry(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		claims  TokenClaims
+		wantErr string
+	}{
+		{"fresh token", TokenClaims{ConsumerId: "acme", IssuedAt: now.Unix()}, ""},
+		{"expired token", TokenClaims{ConsumerId: "acme", IssuedAt: now.Add(-2 * time.Hour).Unix()}, "token expired"},
+		{"scheduled send extends the token", TokenClaims{ConsumerId: "acme", IssuedAt: now.Add(-2 * time.Hour).Unix(), SendAt: now.Add(-time.Minute).Unix()}, ""},
+		{"expired after the scheduled send", TokenClaims{ConsumerId: "acme", IssuedAt: now.Add(-3 * time.Hour).Unix(), SendAt: now.Add(-2 * time.Hour).Unix()}, "token expired"},
+		{"token without consumer", TokenClaims{IssuedAt: now.Unix()}, "no consumer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := NewToken("secret", tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ParseToken("secret", token, time.Hour)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ParseToken() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ParseToken() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	token, _ := NewToken("secret", TokenClaims{ConsumerId: "acme"})
+	if _, err := ParseToken("other", token, time.Hour); err == nil {
+		t.Fatal("ParseToken() accepted a token signed with another secret")
+	}
+}
@@ -0,0 +1,208 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxPayloadSize bounds the size of an accepted webhook request body
+const maxPayloadSize = 1 << 20
+
+// Receiver accepts delivery-status and inbound-message callbacks signed with a shared secret, or
+// sent to a callback URL carrying a token issued by NewToken
+type Receiver struct {
+	Secret    string // HMAC-SHA256 key shared with the provider, also signing callback tokens
+	Header    string // Header carrying the signature of the raw request body
+	Events    *Store
+	Retention time.Duration // How long received events are kept, and callback tokens accepted
+	OnEvent   func(Event)   // Called once for every newly stored event
+}
+
+// Verify reports whether signature is the HMAC-SHA256 of body under secret. Hex and base64
+// encodings are accepted, optionally prefixed with "sha256=".
+func Verify(secret string, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	if decoded, err := hex.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+		return true
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+		return true
+	}
+	return false
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of body under secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxPayloadSize {
+		http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	// Providers cannot sign with our secret, so per-message callback URLs authenticate with their token
+	var claims *TokenClaims
+	if token := r.URL.Query().Get("token"); token != "" {
+		parsed, err := ParseToken(rc.Secret, token, rc.Retention)
+		if err != nil {
+			log.Printf("Rejected webhook from %s: %v", r.RemoteAddr, err)
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		claims = &parsed
+	} else if !Verify(rc.Secret, body, r.Header.Get(rc.Header)) {
+		log.Printf("Rejected webhook from %s: invalid or missing %s signature", r.RemoteAddr, rc.Header)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event, err := Parse(body, r.Header.Get("Content-Type"), r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if claims != nil {
+		event.ConsumerId = claims.ConsumerId
+		if claims.ServiceId != "" {
+			event.ServiceId = claims.ServiceId
+		}
+		if claims.Reference != "" {
+			event.Reference = claims.Reference
+		}
+		event.CallId = claims.CallId
+	}
+	added, err := rc.Events.Add(event, rc.Retention)
+	if err != nil {
+		log.Printf("Failed to store webhook event: %v", err)
+		http.Error(w, "Failed to store event", http.StatusInternalServerError)
+		return
+	}
+	if added && rc.OnEvent != nil {
+		rc.OnEvent(event)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"status":"ok","id":%q}`, event.Id)
+}
+
+// Parse reads an event from a webhook body: an Apideck-style {"payload": {...}} envelope, a
+// message object (optionally wrapped in "data" or "message"), or Twilio-style form fields.
+// consumer_id and service_id query parameters fill in what the payload leaves out. The event ID
+// hashes the query and body, so a provider retrying the same callback yields the same ID.
+func Parse(body []byte, contentType string, query url.Values) (Event, error) {
+	sum := sha256.Sum256(append([]byte(query.Encode()+"\n"), body...))
+	event := Event{Id: hex.EncodeToString(sum[:16]), ReceivedAt: time.Now().UTC()}
+	direction := ""
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return event, fmt.Errorf("invalid form payload: %w", err)
+		}
+		field := func(keys ...string) string {
+			for _, key := range keys {
+				if val := form.Get(key); val != "" {
+					return val
+				}
+			}
+			return ""
+		}
+		event.MessageId = field("MessageSid", "SmsSid", "MessageUUID", "id")
+		event.Status = strings.ToLower(field("MessageStatus", "SmsStatus", "Status", "status"))
+		event.From = field("From", "from")
+		event.To = field("To", "to")
+		event.Body = field("Body", "Text", "body", "text")
+		direction = strings.ToLower(field("Direction", "direction"))
+		values := make(map[string]string, len(form))
+		for key := range form {
+			values[key] = form.Get(key)
+		}
+		event.Payload, _ = json.Marshal(values)
+	} else {
+		var doc map[string]any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return event, fmt.Errorf("invalid JSON payload: %w", err)
+		}
+		event.Payload = json.RawMessage(body)
+		if envelope, ok := doc["payload"].(map[string]any); ok {
+			event.EventType = text(envelope, "event_type")
+			event.ConsumerId = text(envelope, "consumer_id")
+			event.ServiceId = text(envelope, "service_id")
+			event.MessageId = text(envelope, "entity_id")
+			event.OccurredAt = text(envelope, "occurred_at")
+		}
+		msg := doc
+		for _, key := range []string{"data", "message"} {
+			if inner, ok := doc[key].(map[string]any); ok {
+				msg = inner
+				break
+			}
+		}
+		if id := text(msg, "id"); id != "" {
+			event.MessageId = id
+		}
+		event.Status = strings.ToLower(text(msg, "status"))
+		event.From = text(msg, "from")
+		event.To = text(msg, "to")
+		event.Body = text(msg, "body")
+		event.Reference = text(msg, "reference")
+		direction = strings.ToLower(text(msg, "direction"))
+		if event.EventType == "" {
+			event.EventType = text(doc, "event_type", "type", "event")
+		}
+	}
+	if event.MessageId == "" && event.From == "" && event.To == "" {
+		return event, fmt.Errorf("unrecognized payload: no message id or phone numbers")
+	}
+	if event.ConsumerId == "" {
+		event.ConsumerId = query.Get("consumer_id")
+	}
+	if event.ServiceId == "" {
+		event.ServiceId = query.Get("service_id")
+	}
+
+	eventType := strings.ToLower(event.EventType)
+	event.Type = EventDeliveryStatus
+	if direction == "inbound" || event.Status == "received" || event.Status == "receiving" ||
+		strings.Contains(eventType, "inbound") || strings.Contains(eventType, "received") {
+		event.Type = EventInboundMessage
+	}
+	return event, nil
+}
+
+// text returns the first of keys holding a string value in obj
+func text(obj map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if val, ok := obj[key].(string); ok && val != "" {
+			return val
+		}
+	}
+	return ""
+}